@@ -0,0 +1,223 @@
+// Package block holds the block header, which is all an SPV client needs to
+// know of a block to check merkle proofs against its merkle root.
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+// HeaderSize is the size of a serialized block header.
+const HeaderSize = 80
+
+var (
+	// ErrHeaderSize is returned when parsing a header which is not 80 bytes.
+	ErrHeaderSize = fmt.Errorf("block header must be %d bytes", HeaderSize)
+	// ErrInvalidTarget is returned when the bits of a header encode a
+	// negative or zero target, or one above the maximum target.
+	ErrInvalidTarget = errors.New("block header has an invalid target")
+	// ErrProofOfWork is returned when the hash of a header is above its
+	// target.
+	ErrProofOfWork = errors.New("block header hash is above its target")
+)
+
+// MaxTarget is the target of difficulty 1, encoded as 0x1d00ffff, which is
+// the easiest target allowed on mainnet.
+var MaxTarget = CompactToBig(0x1d00ffff)
+
+// Header is a block header, as hashed to identify the block and to prove the
+// work done on it.
+type Header struct {
+	Version    int32
+	PrevHash   chainhash.Hash
+	MerkleRoot chainhash.Hash
+	Timestamp  uint32
+	Bits       uint32
+	Nonce      uint32
+}
+
+// NewHeaderFromBytes parses an 80 byte block header.
+func NewHeaderFromBytes(b []byte) (*Header, error) {
+	if len(b) != HeaderSize {
+		return nil, ErrHeaderSize
+	}
+	h := &Header{}
+	if _, err := h.ReadFrom(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// NewHeaderFromHex parses a hex encoded 80 byte block header.
+func NewHeaderFromHex(s string) (*Header, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return NewHeaderFromBytes(b)
+}
+
+// ReadFrom reads the next 80 byte block header from the `io.Reader`.
+func (h *Header) ReadFrom(r io.Reader) (int64, error) {
+	b := make([]byte, HeaderSize)
+	n, err := io.ReadFull(r, b)
+	if err != nil {
+		return int64(n), err
+	}
+	h.Version = int32(binary.LittleEndian.Uint32(b[0:4]))
+	copy(h.PrevHash[:], b[4:36])
+	copy(h.MerkleRoot[:], b[36:68])
+	h.Timestamp = binary.LittleEndian.Uint32(b[68:72])
+	h.Bits = binary.LittleEndian.Uint32(b[72:76])
+	h.Nonce = binary.LittleEndian.Uint32(b[76:80])
+	return int64(n), nil
+}
+
+// Bytes encodes the header into its 80 byte serialization.
+func (h *Header) Bytes() []byte {
+	b := make([]byte, HeaderSize)
+	binary.LittleEndian.PutUint32(b[0:4], uint32(h.Version))
+	copy(b[4:36], h.PrevHash[:])
+	copy(b[36:68], h.MerkleRoot[:])
+	binary.LittleEndian.PutUint32(b[68:72], h.Timestamp)
+	binary.LittleEndian.PutUint32(b[72:76], h.Bits)
+	binary.LittleEndian.PutUint32(b[76:80], h.Nonce)
+	return b
+}
+
+// WriteTo writes the 80 byte serialization of the header to the `io.Writer`.
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(h.Bytes())
+	return int64(n), err
+}
+
+// Hex encodes the header into the hex of its 80 byte serialization.
+func (h *Header) Hex() string {
+	return hex.EncodeToString(h.Bytes())
+}
+
+// Hash returns the block hash, the double sha256 of the header.
+func (h *Header) Hash() *chainhash.Hash {
+	hash := chainhash.DoubleHashH(h.Bytes())
+	return &hash
+}
+
+// Target returns the target encoded by the bits of the header, which the
+// block hash must not exceed.
+func (h *Header) Target() *big.Int {
+	return CompactToBig(h.Bits)
+}
+
+// CheckProofOfWork checks that the bits encode a valid target no easier than
+// MaxTarget, and that the block hash does not exceed it.
+func (h *Header) CheckProofOfWork() error {
+	return h.checkProofOfWork(MaxTarget)
+}
+
+// CheckProofOfWorkLimit is like CheckProofOfWork but with the easiest target
+// allowed given, for networks such as regtest.
+func (h *Header) CheckProofOfWorkLimit(limit *big.Int) error {
+	return h.checkProofOfWork(limit)
+}
+
+func (h *Header) checkProofOfWork(limit *big.Int) error {
+	target := h.Target()
+	if target.Sign() <= 0 || target.Cmp(limit) > 0 {
+		return fmt.Errorf("%w: %08x", ErrInvalidTarget, h.Bits)
+	}
+	if HashToBig(h.Hash()).Cmp(target) > 0 {
+		return fmt.Errorf("%w: %s", ErrProofOfWork, h.Hash())
+	}
+	return nil
+}
+
+// Work returns the expected number of hashes needed to find a block with the
+// target of the header, 2^256 / (target + 1).
+func (h *Header) Work() *big.Int {
+	target := h.Target()
+	if target.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	denominator := new(big.Int).Add(target, big.NewInt(1))
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), denominator)
+}
+
+// Difficulty returns how many times harder than MaxTarget the target of the
+// header is.
+func (h *Header) Difficulty() float64 {
+	target := h.Target()
+	if target.Sign() <= 0 {
+		return 0
+	}
+	difficulty, _ := new(big.Rat).SetFrac(MaxTarget, target).Float64()
+	return difficulty
+}
+
+// HashToBig interprets a hash as a little endian number, as done when
+// comparing a block hash with its target.
+func HashToBig(hash *chainhash.Hash) *big.Int {
+	b := hash.CloneBytes()
+	for i := 0; i < len(b)/2; i++ {
+		b[i], b[len(b)-1-i] = b[len(b)-1-i], b[i]
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// CompactToBig decodes the compact representation of a target used in the
+// bits of a header: the highest byte is a base 256 exponent, the sign is
+// given by bit 23 and the remaining 23 bits are the mantissa.
+func CompactToBig(compact uint32) *big.Int {
+	mantissa := compact & 0x007fffff
+	isNegative := compact&0x00800000 != 0
+	exponent := uint(compact >> 24)
+
+	var n *big.Int
+	if exponent <= 3 {
+		mantissa >>= 8 * (3 - exponent)
+		n = big.NewInt(int64(mantissa))
+	} else {
+		n = big.NewInt(int64(mantissa))
+		n.Lsh(n, 8*(exponent-3))
+	}
+	if isNegative {
+		n = n.Neg(n)
+	}
+	return n
+}
+
+// BigToCompact encodes a target into the compact representation used in the
+// bits of a header, the reverse of CompactToBig.
+func BigToCompact(n *big.Int) uint32 {
+	if n.Sign() == 0 {
+		return 0
+	}
+
+	var mantissa uint32
+	exponent := uint(len(n.Bytes()))
+	if exponent <= 3 {
+		mantissa = uint32(n.Bits()[0])
+		mantissa <<= 8 * (3 - exponent)
+	} else {
+		mantissa = uint32(new(big.Int).Rsh(new(big.Int).Abs(n), 8*(exponent-3)).Bits()[0])
+	}
+
+	// The sign bit can not be part of the mantissa, so it is shifted out
+	// into the exponent.
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		exponent++
+	}
+
+	compact := uint32(exponent<<24) | mantissa
+	if n.Sign() < 0 {
+		compact |= 0x00800000
+	}
+	return compact
+}
@@ -0,0 +1,65 @@
+package block_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/block"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	genesisHeaderHex = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+	genesisHash      = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	block1HeaderHex  = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+	block1Hash       = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+)
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	genesis, err := block.NewHeaderFromHex(genesisHeaderHex)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), genesis.Version)
+	require.Equal(t, uint32(1231006505), genesis.Timestamp)
+	require.Equal(t, uint32(0x1d00ffff), genesis.Bits)
+	require.Equal(t, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", genesis.MerkleRoot.String())
+	require.Equal(t, genesisHash, genesis.Hash().String())
+	require.Equal(t, genesisHeaderHex, genesis.Hex())
+	require.NoError(t, genesis.CheckProofOfWork())
+	require.Equal(t, float64(1), genesis.Difficulty())
+	require.Equal(t, big.NewInt(0x100010001), genesis.Work())
+
+	block1, err := block.NewHeaderFromHex(block1HeaderHex)
+	require.NoError(t, err)
+	require.Equal(t, block1Hash, block1.Hash().String())
+	require.Equal(t, genesis.Hash(), &block1.PrevHash)
+	require.NoError(t, block1.CheckProofOfWork())
+
+	t.Run("rejects an invalid proof of work", func(t *testing.T) {
+		header := *genesis
+		header.Nonce++
+		require.ErrorIs(t, header.CheckProofOfWork(), block.ErrProofOfWork)
+
+		header = *genesis
+		header.Bits = 0x1d01ffff
+		require.ErrorIs(t, header.CheckProofOfWork(), block.ErrInvalidTarget)
+		header.Bits = 0x1d80ffff
+		require.ErrorIs(t, header.CheckProofOfWork(), block.ErrInvalidTarget)
+	})
+
+	t.Run("rejects invalid lengths", func(t *testing.T) {
+		_, err := block.NewHeaderFromHex(genesisHeaderHex[2:])
+		require.ErrorIs(t, err, block.ErrHeaderSize)
+	})
+}
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+
+	for _, compact := range []uint32{0x1d00ffff, 0x207fffff, 0x1b0404cb, 0x03123456, 0x01120000} {
+		require.Equal(t, compact, block.BigToCompact(block.CompactToBig(compact)))
+	}
+	require.Equal(t, "ffff0000000000000000000000000000000000000000000000000000", block.CompactToBig(0x1d00ffff).Text(16))
+	require.Equal(t, uint32(0), block.BigToCompact(big.NewInt(0)))
+}
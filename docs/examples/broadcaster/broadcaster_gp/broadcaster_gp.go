@@ -13,7 +13,7 @@ func main() {
 
 	// Broadcast the transaction
 	success, failure := tx.Broadcast(&broadcaster.Arc{
-		ApiUrl: "https://arc.gorillapool.io",
+		ApiUrl: "https://arc.gorillapool.io/v1",
 		ApiKey: "",
 	})
 
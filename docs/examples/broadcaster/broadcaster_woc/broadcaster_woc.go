@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/bitcoin-sv/go-sdk/transaction"
 	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"github.com/bitcoin-sv/go-sdk/transaction/whatsonchain"
 )
 
 func main() {
@@ -12,9 +13,7 @@ func main() {
 	tx, _ := transaction.NewTransactionFromHex(hexTx)
 
 	// Broadcast the transaction
-	success, failure := tx.Broadcast(&broadcaster.Arc{
-		ApiUrl: "https://api.whatsonchain.com/v1/bsv/main/tx/raw",
-	})
+	success, failure := tx.Broadcast(broadcaster.NewWhatsOnChain(whatsonchain.MainNet, ""))
 
 	// Check for errors
 	if failure != nil {
@@ -1,71 +1,29 @@
 package main
 
 import (
-	"bytes"
-	"encoding/hex"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-)
-
-// Example extending interface transaction/chaintracker/chaintracker.go
-
-// BlockHeadersClient represents a Block Headers Client.
-type BlockHeadersClient struct {
-	URL    string
-	APIKey string
-}
+	"log"
+	"os"
 
-// NewBlockHeadersClient constructs an instance of the BlockHeadersClient.
-func NewBlockHeadersClient(URL string, APIKey string) *BlockHeadersClient {
-	return &BlockHeadersClient{
-		URL:    URL,
-		APIKey: APIKey,
-	}
-}
-
-// IsValidRootForHeight checks a set of merkle roots with corresponding heights.
-func (b *BlockHeadersClient) IsValidRootForHeight(root []byte, height uint32) (bool, error) {
-	type requestBody struct {
-		MerkleRoot  string `json:"merkleRoot"`
-		BlockHeight uint32 `json:"blockHeight"`
-	}
-
-	payload := []requestBody{{MerkleRoot: hex.EncodeToString(root), BlockHeight: height}}
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return false, fmt.Errorf("error marshaling JSON: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", b.URL+"/api/v1/chain/merkleroot/verify", bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return false, fmt.Errorf("error creating request: %v", err)
-	}
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker/headersclient"
+	"github.com/bitcoin-sv/go-sdk/transaction/spv"
+)
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+b.APIKey)
+// Replace with the BEEF structure you'd like to check
+const BEEFHex = "0100beef01fe636d0c0007021400fe507c0c7aa754cef1f7889d5fd395cf1f785dd7de98eed895dbedfe4e5bc70d1502ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e010b00bc4ff395efd11719b277694cface5aa50d085a0bb81f613f70313acd28cf4557010400574b2d9142b8d28b61d88e3b2c3f44d858411356b49a28a4643b6d1a6a092a5201030051a05fc84d531b5d250c23f4f886f6812f9fe3f402d61607f977b4ecd2701c19010000fd781529d58fc2523cf396a7f25440b409857e7e221766c57214b1d38c7b481f01010062f542f45ea3660f86c013ced80534cb5fd4c19d66c56e7e8c5d4bf2d40acc5e010100b121e91836fd7cd5102b654e9f72f3cf6fdbfd0b161c53a9c54b12c841126331020100000001cd4e4cac3c7b56920d1e7655e7e260d31f29d9a388d04910f1bbd72304a79029010000006b483045022100e75279a205a547c445719420aa3138bf14743e3f42618e5f86a19bde14bb95f7022064777d34776b05d816daf1699493fcdf2ef5a5ab1ad710d9c97bfb5b8f7cef3641210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013e660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000001000100000001ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e000000006a47304402203a61a2e931612b4bda08d541cfb980885173b8dcf64a3471238ae7abcd368d6402204cbf24f04b9aa2256d8901f0ed97866603d2be8324c2bfb7a37bf8fc90edd5b441210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013c660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000000"
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
+func main() {
+	// Point the client to your Block Headers Service
+	client := headersclient.New(os.Getenv("HEADERS_URL"), os.Getenv("HEADERS_API_KEY"))
 
-	body, err := io.ReadAll(resp.Body)
+	tx, err := transaction.NewTransactionFromBEEFHex(BEEFHex)
 	if err != nil {
-		return false, fmt.Errorf("error reading response body: %v", err)
-	}
-
-	var response struct {
-		ConfirmationState string `json:"confirmationState"`
+		log.Fatal(err)
 	}
-
-	err = json.Unmarshal(body, &response)
+	// Merkle roots of the BEEF are confirmed against the headers service
+	verified, err := spv.Verify(tx, client, nil)
 	if err != nil {
-		return false, fmt.Errorf("error unmarshaling JSON: %v", err)
+		log.Fatal(err)
 	}
-
-	return response.ConfirmationState == "CONFIRMED", nil
+	log.Println("verified:", verified)
 }
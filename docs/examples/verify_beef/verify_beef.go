@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
+
 	"github.com/bitcoin-sv/go-sdk/chainhash"
 	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/spv"
 )
 
 type GullibleHeadersClient struct{}
 
-func (g *GullibleHeadersClient) IsValidRootForHeight(merkleRoot *chainhash.Hash, height uint32) bool {
+func (g *GullibleHeadersClient) IsValidRootForHeight(ctx context.Context, merkleRoot *chainhash.Hash, height uint32) (bool, error) {
 	// DO NOT USE IN A REAL PROJECT due to security risks of accepting any merkle root as valid without verification
-	return true
+	return true, nil
+}
+
+func (g *GullibleHeadersClient) CurrentHeight(ctx context.Context) (uint32, error) {
+	return 0, nil
 }
 
 // Replace with the BEEF structure you'd like to check
@@ -22,6 +29,9 @@ func main() {
 		panic(err)
 	}
 	// This ensures the BEEF structure is legitimate
-	verified, _ := tx.MerklePath.Verify(tx.TxID(), &GullibleHeadersClient{})
+	verified, err := spv.Verify(tx, &GullibleHeadersClient{}, nil)
+	if err != nil {
+		panic(err)
+	}
 	println(verified)
 }
@@ -5,167 +5,877 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"slices"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/pkg/errors"
 )
 
-func (t *Transaction) FromBEEF(beef []byte) error {
-	tx, err := NewTransactionFromBEEF(beef)
-	*t = *tx
-	return err
+const (
+	// BeefV1 is the version number of BEEF as defined by BRC-62.
+	BeefV1 uint32 = 4022206465
+	// BeefV2 is the version number of BEEF as defined by BRC-96, which allows
+	// transactions to be given by txid only.
+	BeefV2 uint32 = 4022206466
+	// AtomicBeefPrefix starts an Atomic BEEF (BRC-95), followed by the txid
+	// of its subject transaction and a BEEF.
+	AtomicBeefPrefix uint32 = 0x01010101
+)
+
+// Transaction data formats of BEEF V2.
+const (
+	beefRawTx             byte = 0
+	beefRawTxAndBumpIndex byte = 1
+	beefTxidOnly          byte = 2
+)
+
+// BeefTx is a transaction held by a Beef.
+type BeefTx struct {
+	// Transaction is nil when the transaction is only known by its txid.
+	Transaction *Transaction
+	// KnownTxID is the txid of a transaction known elsewhere, which is only
+	// set when Transaction is nil. It can only be serialized in BEEF V2.
+	KnownTxID *chainhash.Hash
+	// BumpIndex is the index in Beef.BUMPs of the merkle path proving the
+	// transaction, or -1 when the transaction is not proven.
+	BumpIndex int
 }
 
-func NewTransactionFromBEEF(beef []byte) (*Transaction, error) {
+// TxID returns the txid of the transaction.
+func (btx *BeefTx) TxID() *chainhash.Hash {
+	if btx.Transaction != nil {
+		return btx.Transaction.TxID()
+	}
+	return btx.KnownTxID
+}
+
+// Beef is a set of transactions together with the BUMPs proving their
+// ancestry, as exchanged in the BEEF format (https://brc.dev/62).
+//
+// Unlike NewTransactionFromBEEF, which only returns the last transaction,
+// Beef keeps every transaction, allowing several subject transactions to
+// share their ancestry.
+//
+// Version selects between BEEF V1 and V2 when serializing. When AtomicTxID is
+// set the Beef is serialized as an Atomic BEEF for that subject. Changing
+// both converts between the formats.
+type Beef struct {
+	Version    uint32
+	BUMPs      []*MerklePath
+	AtomicTxID *chainhash.Hash
+
+	txs   []*BeefTx
+	index map[string]*BeefTx
+}
+
+// NewBeef creates an empty Beef.
+func NewBeef() *Beef {
+	return &Beef{
+		Version: BeefV1,
+		BUMPs:   make([]*MerklePath, 0),
+		txs:     make([]*BeefTx, 0),
+		index:   make(map[string]*BeefTx),
+	}
+}
+
+// NewBeefFromHex creates a Beef from a hex encoded BEEF.
+func NewBeefFromHex(beefHex string) (*Beef, error) {
+	beef, err := hex.DecodeString(beefHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewBeefFromBytes(beef)
+}
+
+// NewBeefFromBytes creates a Beef from BEEF V1, BEEF V2 or Atomic BEEF bytes.
+// The decoded Beef is checked with Validate, and errors wrap the ErrBeef
+// sentinels so callers can tell malformed input apart with errors.Is.
+func NewBeefFromBytes(beef []byte) (*Beef, error) {
 	reader := bytes.NewReader(beef)
+	b, err := readBeef(reader, BeefLimits{})
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrBeefTrailingBytes, reader.Len())
+	}
+	return b, nil
+}
+
+// BeefLimits bounds the resources spent decoding a BEEF from an untrusted
+// source. A zero field means no limit.
+type BeefLimits struct {
+	// MaxBytes is the maximum size of the encoded BEEF.
+	MaxBytes int64
+	// MaxBUMPs is the maximum number of BUMPs.
+	MaxBUMPs uint64
+	// MaxTransactions is the maximum number of transactions, including
+	// transactions given by txid only.
+	MaxTransactions uint64
+}
 
+// NewBeefFromReader decodes a BEEF V1, BEEF V2 or Atomic BEEF incrementally
+// from the `io.Reader`, failing with ErrBeefLimitExceeded as soon as one of
+// the limits is exceeded. It reads exactly the bytes of the BEEF, so r is
+// left positioned after it. As the decoder issues many small reads, an
+// unbuffered r such as a network connection should be wrapped in a
+// bufio.Reader.
+func NewBeefFromReader(r io.Reader, limits BeefLimits) (*Beef, error) {
+	if limits.MaxBytes > 0 {
+		r = &beefLimitReader{r: r, max: limits.MaxBytes, remaining: limits.MaxBytes}
+	}
+	return readBeef(r, limits)
+}
+
+// beefLimitReader fails once more than the allowed number of bytes are
+// needed, unlike io.LimitReader which reports a plain io.EOF.
+type beefLimitReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+}
+
+func (l *beefLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrBeefLimitExceeded, l.max)
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func readBeef(reader io.Reader, limits BeefLimits) (*Beef, error) {
 	var version uint32
 	err := binary.Read(reader, binary.LittleEndian, &version)
 	if err != nil {
 		return nil, err
 	}
-	if version != 4022206465 {
-		return nil, fmt.Errorf("invalid BEEF version. expected 4022206465, received %d", version)
+
+	var atomicTxID *chainhash.Hash
+	if version == AtomicBeefPrefix {
+		txid := make([]byte, chainhash.HashSize)
+		if _, err = io.ReadFull(reader, txid); err != nil {
+			return nil, err
+		}
+		if atomicTxID, err = chainhash.NewHash(txid); err != nil {
+			return nil, err
+		}
+		if err = binary.Read(reader, binary.LittleEndian, &version); err != nil {
+			return nil, err
+		}
+	}
+	if version != BeefV1 && version != BeefV2 {
+		return nil, fmt.Errorf("%w: expected %d or %d, received %d", ErrBeefVersion, BeefV1, BeefV2, version)
 	}
 
-	// Read the BUMPs
+	b := NewBeef()
+	b.Version = version
+	b.AtomicTxID = atomicTxID
+
+	// Read the BUMPs. The counts are not trusted for preallocation, a
+	// malformed BEEF fails on the first missing entry instead.
 	var numberOfBUMPs VarInt
 	_, err = numberOfBUMPs.ReadFrom(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	BUMPs := make([]*MerklePath, numberOfBUMPs)
-	for i := 0; i < int(numberOfBUMPs); i++ {
-		BUMPs[i], err = NewMerklePathFromReader(reader)
+	if limits.MaxBUMPs > 0 && uint64(numberOfBUMPs) > limits.MaxBUMPs {
+		return nil, fmt.Errorf("%w: %d BUMPs", ErrBeefLimitExceeded, numberOfBUMPs)
+	}
+	for i := uint64(0); i < uint64(numberOfBUMPs); i++ {
+		bump, err := NewMerklePathFromReader(reader)
 		if err != nil {
 			return nil, err
 		}
+		b.BUMPs = append(b.BUMPs, bump)
 	}
 
-	// Read all transactions into an object
+	// Read the transactions, which are ordered so that parents come first
 	var numberOfTransactions VarInt
 	_, err = numberOfTransactions.ReadFrom(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	transactions := make(map[string]*Transaction, 0)
-	var tx *Transaction
-	for i := 0; i < int(numberOfTransactions); i++ {
-		tx = &Transaction{}
-		_, err = tx.ReadFrom(reader)
+	if limits.MaxTransactions > 0 && uint64(numberOfTransactions) > limits.MaxTransactions {
+		return nil, fmt.Errorf("%w: %d transactions", ErrBeefLimitExceeded, numberOfTransactions)
+	}
+	for i := uint64(0); i < uint64(numberOfTransactions); i++ {
+		var btx *BeefTx
+		if version == BeefV2 {
+			btx, err = b.readTxV2(reader)
+		} else {
+			btx, err = b.readTxV1(reader)
+		}
 		if err != nil {
 			return nil, err
 		}
-		txid := tx.TxID()
+		if _, ok := b.index[btx.TxID().String()]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrBeefDuplicateTx, btx.TxID())
+		}
+		b.add(btx)
+	}
 
-		hasBump := make([]byte, 1)
-		_, err = reader.Read(hasBump)
+	if err = b.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, btx := range b.txs {
+		tx := btx.Transaction
+		if tx == nil {
+			continue
+		}
+		if btx.BumpIndex >= 0 {
+			tx.MerklePath = b.BUMPs[btx.BumpIndex]
+		}
+		for _, input := range tx.Inputs {
+			if source, ok := b.index[input.SourceTXID.String()]; ok {
+				input.SourceTransaction = source.Transaction
+			}
+		}
+	}
+
+	return b, nil
+}
+
+// Validate checks the structure of the Beef without consulting a chain
+// tracker: every BUMP index is in range, no transaction appears twice,
+// transactions come after the transactions they spend, unproven
+// transactions only spend transactions of the Beef, each BUMP proves the
+// transactions referencing it and BUMPs at the same height share a root.
+func (b *Beef) Validate() error {
+	if b.Version != BeefV1 && b.Version != BeefV2 {
+		return fmt.Errorf("%w: %d", ErrBeefVersion, b.Version)
+	}
+
+	roots := make(map[uint32]*chainhash.Hash, len(b.BUMPs))
+	for i, bump := range b.BUMPs {
+		root, err := bump.ComputeRoot(nil)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("BUMP %d: %w", i, err)
+		}
+		if other, ok := roots[bump.BlockHeight]; ok && !other.IsEqual(root) {
+			return fmt.Errorf("%w: height %d", ErrBeefBumpRootMismatch, bump.BlockHeight)
 		}
-		if hasBump[0] != 0 {
-			var pathIndex VarInt
-			_, err = pathIndex.ReadFrom(reader)
-			if err != nil {
-				return nil, err
+		roots[bump.BlockHeight] = root
+	}
+
+	positions := make(map[string]int, len(b.txs))
+	for i, btx := range b.txs {
+		txid := btx.TxID()
+		if txid == nil {
+			return fmt.Errorf("%w: transaction %d has no txid", ErrBeefTxFormat, i)
+		}
+		if btx.Transaction == nil && b.Version == BeefV1 {
+			return fmt.Errorf("%w: BEEF V1 cannot hold txid only transaction %s", ErrBeefTxFormat, txid)
+		}
+		if _, ok := positions[txid.String()]; ok {
+			return fmt.Errorf("%w: %s", ErrBeefDuplicateTx, txid)
+		}
+		positions[txid.String()] = i
+	}
+
+	for i, btx := range b.txs {
+		txid := btx.TxID()
+		if btx.BumpIndex < -1 || btx.BumpIndex >= len(b.BUMPs) {
+			return fmt.Errorf("%w: %d for %s", ErrBeefBumpIndex, btx.BumpIndex, txid)
+		}
+		if btx.BumpIndex >= 0 {
+			bump := b.BUMPs[btx.BumpIndex]
+			if !slices.ContainsFunc(bump.Path[0], func(leaf *PathElement) bool {
+				return leaf.Hash != nil && leaf.Hash.IsEqual(txid)
+			}) {
+				return fmt.Errorf("%w: %s", ErrBeefBumpMissingTx, txid)
+			}
+			if _, err := bump.ComputeRoot(txid); err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrBeefBumpMissingTx, txid, err)
 			}
-			tx.MerklePath = BUMPs[int(pathIndex)]
 		}
-		for _, input := range tx.Inputs {
-			sourceTxid := input.SourceTXID.String()
-			if sourceObj, ok := transactions[sourceTxid]; ok {
-				input.SourceTransaction = sourceObj
-			} else if tx.MerklePath == nil {
-				panic(fmt.Sprintf("Reference to unknown TXID in BUMP: %s", sourceTxid))
+		if btx.Transaction == nil {
+			continue
+		}
+		for _, input := range btx.Transaction.Inputs {
+			position, ok := positions[input.SourceTXID.String()]
+			switch {
+			case ok && position > i:
+				return fmt.Errorf("%w: %s spends %s", ErrBeefTxOrder, txid, input.SourceTXID)
+			case !ok && btx.BumpIndex < 0:
+				return fmt.Errorf("%w: %s spends %s", ErrBeefUnknownSource, txid, input.SourceTXID)
 			}
 		}
-		transactions[txid.String()] = tx
 	}
 
-	return tx, nil
+	if b.AtomicTxID != nil {
+		if _, ok := positions[b.AtomicTxID.String()]; !ok {
+			return fmt.Errorf("%w: %s", ErrBeefAtomicSubject, b.AtomicTxID)
+		}
+	}
+	return nil
 }
 
-func NewTransactionFromBEEFHex(beefHex string) (*Transaction, error) {
-	if beef, err := hex.DecodeString(beefHex); err != nil {
+func (b *Beef) readTxV1(reader io.Reader) (*BeefTx, error) {
+	tx := &Transaction{}
+	if _, err := tx.ReadFrom(reader); err != nil {
 		return nil, err
-	} else {
-		return NewTransactionFromBEEF(beef)
 	}
+	btx := &BeefTx{Transaction: tx, BumpIndex: -1}
+
+	hasBump := make([]byte, 1)
+	if _, err := io.ReadFull(reader, hasBump); err != nil {
+		return nil, err
+	}
+	if hasBump[0] != 0 {
+		bumpIndex, err := b.readBumpIndex(reader)
+		if err != nil {
+			return nil, err
+		}
+		btx.BumpIndex = bumpIndex
+	}
+	return btx, nil
 }
 
-func (t *Transaction) BEEF() ([]byte, error) {
-	b := new(bytes.Buffer)
-	err := binary.Write(b, binary.LittleEndian, uint32(4022206465))
-	if err != nil {
+func (b *Beef) readTxV2(reader io.Reader) (*BeefTx, error) {
+	format := make([]byte, 1)
+	if _, err := io.ReadFull(reader, format); err != nil {
 		return nil, err
 	}
-	bumps := map[uint32]*MerklePath{}
-	bumpIndex := map[uint32]int{}
-	txns := map[string]*Transaction{t.TxID().String(): t}
-	ancestors, err := t.collectAncestors(txns)
-	if err != nil {
+	btx := &BeefTx{BumpIndex: -1}
+
+	switch format[0] {
+	case beefTxidOnly:
+		txid := make([]byte, chainhash.HashSize)
+		if _, err := io.ReadFull(reader, txid); err != nil {
+			return nil, err
+		}
+		hash, err := chainhash.NewHash(txid)
+		if err != nil {
+			return nil, err
+		}
+		btx.KnownTxID = hash
+		return btx, nil
+	case beefRawTxAndBumpIndex:
+		bumpIndex, err := b.readBumpIndex(reader)
+		if err != nil {
+			return nil, err
+		}
+		btx.BumpIndex = bumpIndex
+	case beefRawTx:
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrBeefTxFormat, format[0])
+	}
+
+	btx.Transaction = &Transaction{}
+	if _, err := btx.Transaction.ReadFrom(reader); err != nil {
 		return nil, err
 	}
-	for _, txid := range ancestors {
-		tx := txns[txid]
-		if tx.MerklePath == nil {
+	return btx, nil
+}
+
+func (b *Beef) readBumpIndex(reader io.Reader) (int, error) {
+	var pathIndex VarInt
+	if _, err := pathIndex.ReadFrom(reader); err != nil {
+		return -1, err
+	}
+	if uint64(pathIndex) >= uint64(len(b.BUMPs)) {
+		return -1, fmt.Errorf("%w: %d", ErrBeefBumpIndex, pathIndex)
+	}
+	return int(pathIndex), nil
+}
+
+// Transactions returns the transactions of the Beef, parents first.
+func (b *Beef) Transactions() []*BeefTx {
+	return slices.Clone(b.txs)
+}
+
+// FindTransaction returns the transaction with the given txid, or nil if the
+// Beef does not hold it or only knows its txid.
+func (b *Beef) FindTransaction(txid string) *Transaction {
+	if btx, ok := b.index[txid]; ok {
+		return btx.Transaction
+	}
+	return nil
+}
+
+// FindBump returns the merkle path proving the transaction with the given
+// txid, or nil if the transaction is unknown or unproven.
+func (b *Beef) FindBump(txid string) *MerklePath {
+	if btx, ok := b.index[txid]; ok && btx.BumpIndex >= 0 {
+		return b.BUMPs[btx.BumpIndex]
+	}
+	return nil
+}
+
+// Subjects returns the transactions which are not spent by any other
+// transaction in the Beef. Transactions known by txid only are never
+// subjects.
+func (b *Beef) Subjects() []*Transaction {
+	spent := make(map[string]struct{}, len(b.txs))
+	for _, btx := range b.txs {
+		if btx.Transaction == nil {
 			continue
 		}
-		if _, ok := bumps[tx.MerklePath.BlockHeight]; !ok {
-			bumpIndex[tx.MerklePath.BlockHeight] = len(bumps)
-			bumps[tx.MerklePath.BlockHeight] = tx.MerklePath
+		for _, input := range btx.Transaction.Inputs {
+			spent[input.SourceTXID.String()] = struct{}{}
+		}
+	}
+	subjects := make([]*Transaction, 0)
+	for _, btx := range b.txs {
+		if btx.Transaction == nil {
+			continue
+		}
+		if _, ok := spent[btx.Transaction.TxID().String()]; !ok {
+			subjects = append(subjects, btx.Transaction)
+		}
+	}
+	return subjects
+}
+
+// MergeBump adds a merkle path to the Beef and returns its index in BUMPs.
+// A path at a block height already present is combined with the existing
+// one. Transactions of the Beef proven by the path are marked as such.
+//
+// The path is copied, and paths are combined into a copy, as they may be
+// shared with transactions outside the Beef.
+func (b *Beef) MergeBump(bump *MerklePath) (int, error) {
+	bumpIndex := -1
+	for i, existing := range b.BUMPs {
+		if existing.BlockHeight == bump.BlockHeight {
+			combined := existing.Clone()
+			if err := combined.Combine(bump.Clone()); err != nil {
+				return -1, err
+			}
+			b.BUMPs[i] = combined
+			for _, btx := range b.index {
+				if btx.BumpIndex == i && btx.Transaction != nil {
+					btx.Transaction.MerklePath = combined
+				}
+			}
+			bumpIndex = i
+			break
+		}
+	}
+	if bumpIndex < 0 {
+		bumpIndex = len(b.BUMPs)
+		b.BUMPs = append(b.BUMPs, bump.Clone())
+	}
+
+	merged := b.BUMPs[bumpIndex]
+	for _, leaf := range merged.Path[0] {
+		if leaf.Hash == nil {
+			continue
+		}
+		if btx, ok := b.index[leaf.Hash.String()]; ok && btx.BumpIndex < 0 {
+			btx.BumpIndex = bumpIndex
+			if btx.Transaction != nil {
+				btx.Transaction.MerklePath = merged
+			}
+		}
+	}
+	return bumpIndex, nil
+}
+
+// MergeTransaction adds a transaction to the Beef, along with its merkle path
+// or, when it is unproven, the ancestry reachable through each input's
+// SourceTransaction. A transaction previously known by txid only is
+// replaced by the full transaction.
+func (b *Beef) MergeTransaction(tx *Transaction) error {
+	txid := tx.TxID().String()
+	existing, ok := b.index[txid]
+	if ok && existing.Transaction != nil {
+		if existing.BumpIndex < 0 && tx.MerklePath != nil {
+			_, err := b.MergeBump(tx.MerklePath)
+			return err
+		}
+		return nil
+	}
+
+	btx := &BeefTx{Transaction: tx, BumpIndex: -1}
+	if tx.MerklePath != nil {
+		bumpIndex, err := b.MergeBump(tx.MerklePath)
+		if err != nil {
+			return err
+		}
+		btx.BumpIndex = bumpIndex
+	} else {
+		for _, input := range tx.Inputs {
+			if _, ok := b.index[input.SourceTXID.String()]; ok {
+				continue
+			}
+			if input.SourceTransaction == nil {
+				return fmt.Errorf("%w: %s spends %s", ErrBeefMissingSourceTx, txid, input.SourceTXID)
+			}
+			if err := b.MergeTransaction(input.SourceTransaction); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ok {
+		if btx.BumpIndex < 0 && existing.BumpIndex >= 0 {
+			btx.BumpIndex = existing.BumpIndex
+			tx.MerklePath = b.BUMPs[btx.BumpIndex]
+		}
+		*existing = *btx
+		b.linkSpends(existing)
+		b.txs = b.sortedTransactions()
+		return nil
+	}
+	b.add(btx)
+	return nil
+}
+
+// MergeTxidOnly adds a transaction known elsewhere by its txid. Such entries
+// can only be serialized in BEEF V2.
+func (b *Beef) MergeTxidOnly(txid *chainhash.Hash) {
+	if _, ok := b.index[txid.String()]; ok {
+		return
+	}
+	b.add(&BeefTx{KnownTxID: txid, BumpIndex: -1})
+}
+
+// MergeBeef adds the BUMPs and transactions of another Beef, skipping
+// transactions already present and combining BUMPs at the same height.
+func (b *Beef) MergeBeef(other *Beef) error {
+	for _, bump := range other.BUMPs {
+		if _, err := b.MergeBump(bump); err != nil {
+			return err
+		}
+	}
+	for _, otherTx := range other.txs {
+		existing, ok := b.index[otherTx.TxID().String()]
+		if ok && (existing.Transaction != nil || otherTx.Transaction == nil) {
+			continue
+		}
+		btx := &BeefTx{
+			Transaction: otherTx.Transaction,
+			KnownTxID:   otherTx.KnownTxID,
+			BumpIndex:   -1,
+		}
+		if otherTx.BumpIndex >= 0 {
+			height := other.BUMPs[otherTx.BumpIndex].BlockHeight
+			btx.BumpIndex = slices.IndexFunc(b.BUMPs, func(mp *MerklePath) bool {
+				return mp.BlockHeight == height
+			})
+			if btx.Transaction != nil {
+				btx.Transaction.MerklePath = b.BUMPs[btx.BumpIndex]
+			}
+		}
+		if ok {
+			*existing = *btx
+			b.linkSpends(existing)
 		} else {
-			err := bumps[tx.MerklePath.BlockHeight].Combine(tx.MerklePath)
-			if err != nil {
-				return nil, err
+			b.add(btx)
+		}
+	}
+	b.txs = b.sortedTransactions()
+	return nil
+}
+
+// Trim removes the transactions which are not needed to prove the subject
+// transactions, that is the ancestors of proven transactions, as well as the
+// BUMPs no longer referenced.
+func (b *Beef) Trim() {
+	b.keepAncestry(b.Subjects()...)
+}
+
+// Bytes serializes the Beef according to its Version, as an Atomic BEEF when
+// AtomicTxID is set.
+func (b *Beef) Bytes() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := b.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo writes the Beef to the `io.Writer` in the same format as Bytes,
+// without buffering it in memory first. Nothing is written when the Beef
+// cannot be serialized.
+func (b *Beef) WriteTo(w io.Writer) (int64, error) {
+	if b.Version != BeefV1 && b.Version != BeefV2 {
+		return 0, fmt.Errorf("%w: %d", ErrBeefVersion, b.Version)
+	}
+	if b.AtomicTxID != nil {
+		if _, ok := b.index[b.AtomicTxID.String()]; !ok {
+			return 0, fmt.Errorf("%w: %s", ErrBeefAtomicSubject, b.AtomicTxID)
+		}
+	}
+	txs := b.sortedTransactions()
+	if b.Version == BeefV1 {
+		for _, btx := range txs {
+			if btx.Transaction == nil {
+				return 0, fmt.Errorf("%w: BEEF V1 cannot hold txid only transaction %s", ErrBeefTxFormat, btx.KnownTxID)
 			}
 		}
 	}
 
-	b.Write(VarInt(len(bumps)).Bytes())
-	for _, bump := range bumps {
-		b.Write(bump.Bytes())
+	cw := &countingWriter{w: w}
+	if b.AtomicTxID != nil {
+		cw.writeUint32(AtomicBeefPrefix)
+		cw.write(b.AtomicTxID.CloneBytes())
 	}
-	b.Write(VarInt(len(txns)).Bytes())
-	for _, txid := range ancestors {
-		tx := txns[txid]
-		b.Write(tx.Bytes())
-		if tx.MerklePath != nil {
-			b.Write([]byte{1})
-			b.Write(VarInt(bumpIndex[tx.MerklePath.BlockHeight]).Bytes())
+	cw.writeUint32(b.Version)
+
+	cw.writeVarInt(VarInt(len(b.BUMPs)))
+	for _, bump := range b.BUMPs {
+		bump.writeTo(cw)
+	}
+
+	cw.writeVarInt(VarInt(len(txs)))
+	for _, btx := range txs {
+		if b.Version == BeefV2 {
+			switch {
+			case btx.Transaction == nil:
+				cw.writeByte(beefTxidOnly)
+				cw.write(btx.KnownTxID.CloneBytes())
+				continue
+			case btx.BumpIndex >= 0:
+				cw.writeByte(beefRawTxAndBumpIndex)
+				cw.writeVarInt(VarInt(btx.BumpIndex))
+			default:
+				cw.writeByte(beefRawTx)
+			}
+			btx.Transaction.writeTo(cw, 0, nil, false)
+			continue
+		}
+
+		btx.Transaction.writeTo(cw, 0, nil, false)
+		if btx.BumpIndex >= 0 {
+			cw.writeByte(1)
+			cw.writeVarInt(VarInt(btx.BumpIndex))
 		} else {
-			b.Write([]byte{0})
+			cw.writeByte(0)
 		}
 	}
-	return b.Bytes(), nil
+	return cw.n, cw.err
 }
 
-func (t *Transaction) BEEFHex() (string, error) {
-	if beef, err := t.BEEF(); err != nil {
+// Hex serializes the Beef into a hex string.
+func (b *Beef) Hex() (string, error) {
+	beef, err := b.Bytes()
+	if err != nil {
 		return "", err
-	} else {
-		return hex.EncodeToString(beef), nil
 	}
+	return hex.EncodeToString(beef), nil
 }
 
-func (t *Transaction) collectAncestors(txns map[string]*Transaction) ([]string, error) {
-	if t.MerklePath != nil {
-		return []string{t.TxID().String()}, nil
+// AtomicBytes serializes the ancestry of the transaction with the given txid
+// as an Atomic BEEF, leaving out every unrelated transaction.
+func (b *Beef) AtomicBytes(txid *chainhash.Hash) ([]byte, error) {
+	subject := b.FindTransaction(txid.String())
+	if subject == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBeefAtomicSubject, txid)
 	}
-	ancestors := make([]string, 0)
-	for _, input := range t.Inputs {
-		if input.SourceTransaction == nil {
-			return nil, fmt.Errorf("missing previous transaction for %s", t.TxID())
+	atomic := b.clone()
+	atomic.AtomicTxID = txid
+	atomic.keepAncestry(atomic.FindTransaction(txid.String()))
+	return atomic.Bytes()
+}
+
+// subject returns the transaction named by AtomicTxID, or the last
+// transaction of a plain BEEF.
+func (b *Beef) subject() (*Transaction, error) {
+	if b.AtomicTxID != nil {
+		if tx := b.FindTransaction(b.AtomicTxID.String()); tx != nil {
+			return tx, nil
 		}
-		if _, ok := txns[input.SourceTXID.String()]; ok {
+		return nil, fmt.Errorf("atomic BEEF subject %s is txid only", b.AtomicTxID)
+	}
+	if len(b.txs) == 0 || b.txs[len(b.txs)-1].Transaction == nil {
+		return nil, errors.New("BEEF does not end with a transaction")
+	}
+	return b.txs[len(b.txs)-1].Transaction, nil
+}
+
+func (b *Beef) add(btx *BeefTx) {
+	b.txs = append(b.txs, btx)
+	b.index[btx.TxID().String()] = btx
+}
+
+// linkSpends sets the SourceTransaction of the inputs spending a transaction
+// which was previously known by txid only.
+func (b *Beef) linkSpends(source *BeefTx) {
+	txid := source.TxID()
+	for _, btx := range b.txs {
+		if btx.Transaction == nil {
 			continue
 		}
-		txns[input.SourceTXID.String()] = input.SourceTransaction
-		if grands, err := input.SourceTransaction.collectAncestors(txns); err != nil {
-			return nil, err
-		} else {
-			ancestors = append(grands, ancestors...)
+		for _, input := range btx.Transaction.Inputs {
+			if input.SourceTransaction == nil && input.SourceTXID.IsEqual(txid) {
+				input.SourceTransaction = source.Transaction
+			}
+		}
+	}
+}
+
+// clone copies the Beef entries and BUMPs, the transactions are shared.
+func (b *Beef) clone() *Beef {
+	c := NewBeef()
+	c.Version = b.Version
+	c.AtomicTxID = b.AtomicTxID
+	for _, bump := range b.BUMPs {
+		c.BUMPs = append(c.BUMPs, bump.Clone())
+	}
+	for _, btx := range b.txs {
+		entry := *btx
+		c.add(&entry)
+	}
+	return c
+}
+
+// keepAncestry removes every transaction which is not one of the given
+// transactions or needed to prove them, then drops unreferenced BUMPs.
+func (b *Beef) keepAncestry(txs ...*Transaction) {
+	needed := make(map[string]struct{}, len(b.txs))
+	var visit func(txid string)
+	visit = func(txid string) {
+		if _, ok := needed[txid]; ok {
+			return
+		}
+		btx, ok := b.index[txid]
+		if !ok {
+			return
+		}
+		needed[txid] = struct{}{}
+		if btx.BumpIndex >= 0 || btx.Transaction == nil {
+			return
+		}
+		for _, input := range btx.Transaction.Inputs {
+			visit(input.SourceTXID.String())
+		}
+	}
+	for _, tx := range txs {
+		visit(tx.TxID().String())
+	}
+
+	entries := b.txs
+	b.txs = make([]*BeefTx, 0, len(needed))
+	b.index = make(map[string]*BeefTx, len(needed))
+	for _, btx := range entries {
+		if _, ok := needed[btx.TxID().String()]; ok {
+			b.add(btx)
 		}
 	}
-	ancestors = append(ancestors, t.TxID().String())
-	return ancestors, nil
+
+	bumps := b.BUMPs
+	remap := make(map[int]int, len(bumps))
+	b.BUMPs = make([]*MerklePath, 0, len(bumps))
+	for _, btx := range b.txs {
+		if btx.BumpIndex < 0 {
+			continue
+		}
+		if _, ok := remap[btx.BumpIndex]; !ok {
+			remap[btx.BumpIndex] = len(b.BUMPs)
+			b.BUMPs = append(b.BUMPs, bumps[btx.BumpIndex])
+		}
+		btx.BumpIndex = remap[btx.BumpIndex]
+	}
+}
+
+// sortedTransactions orders the transactions so that every transaction comes
+// after the transactions it spends, keeping the insertion order otherwise.
+func (b *Beef) sortedTransactions() []*BeefTx {
+	sorted := make([]*BeefTx, 0, len(b.txs))
+	added := make(map[string]struct{}, len(b.txs))
+	var visit func(btx *BeefTx)
+	visit = func(btx *BeefTx) {
+		txid := btx.TxID().String()
+		if _, ok := added[txid]; ok {
+			return
+		}
+		added[txid] = struct{}{}
+		if btx.Transaction != nil {
+			for _, input := range btx.Transaction.Inputs {
+				if source, ok := b.index[input.SourceTXID.String()]; ok {
+					visit(source)
+				}
+			}
+		}
+		sorted = append(sorted, btx)
+	}
+	for _, btx := range b.txs {
+		visit(btx)
+	}
+	return sorted
+}
+
+func (t *Transaction) FromBEEF(beef []byte) error {
+	tx, err := NewTransactionFromBEEF(beef)
+	if err != nil {
+		return err
+	}
+	*t = *tx
+	return nil
+}
+
+// NewTransactionFromBEEF parses a BEEF and returns its subject transaction,
+// with the ancestry linked through each input's SourceTransaction. The
+// subject is the last transaction, or the one named by an Atomic BEEF.
+func NewTransactionFromBEEF(beef []byte) (*Transaction, error) {
+	b, err := NewBeefFromBytes(beef)
+	if err != nil {
+		return nil, err
+	}
+	return b.subject()
+}
+
+// NewTransactionFromBEEFReader is like NewTransactionFromBEEF but decodes the
+// BEEF incrementally from the `io.Reader`, see NewBeefFromReader.
+func NewTransactionFromBEEFReader(r io.Reader, limits BeefLimits) (*Transaction, error) {
+	b, err := NewBeefFromReader(r, limits)
+	if err != nil {
+		return nil, err
+	}
+	return b.subject()
+}
+
+func NewTransactionFromBEEFHex(beefHex string) (*Transaction, error) {
+	if beef, err := hex.DecodeString(beefHex); err != nil {
+		return nil, err
+	} else {
+		return NewTransactionFromBEEF(beef)
+	}
+}
+
+// BEEF serializes the transaction and its ancestry according to BRC-62.
+func (t *Transaction) BEEF() ([]byte, error) {
+	b := NewBeef()
+	if err := b.MergeTransaction(t); err != nil {
+		return nil, err
+	}
+	return b.Bytes()
+}
+
+// WriteBEEFTo writes the transaction and its ancestry to the `io.Writer`
+// according to BRC-62, without buffering the BEEF in memory first.
+func (t *Transaction) WriteBEEFTo(w io.Writer) (int64, error) {
+	b := NewBeef()
+	if err := b.MergeTransaction(t); err != nil {
+		return 0, err
+	}
+	return b.WriteTo(w)
+}
+
+func (t *Transaction) BEEFHex() (string, error) {
+	if beef, err := t.BEEF(); err != nil {
+		return "", err
+	} else {
+		return hex.EncodeToString(beef), nil
+	}
+}
+
+// AtomicBEEF serializes the transaction and its ancestry as an Atomic BEEF
+// (BRC-95).
+func (t *Transaction) AtomicBEEF() ([]byte, error) {
+	b := NewBeef()
+	if err := b.MergeTransaction(t); err != nil {
+		return nil, err
+	}
+	b.AtomicTxID = t.TxID()
+	return b.Bytes()
 }
@@ -0,0 +1,407 @@
+package transaction_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"slices"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeefFromBytes(t *testing.T) {
+	t.Parallel()
+
+	beef, err := transaction.NewBeefFromHex(BRC62Hex)
+	require.NoError(t, err)
+	require.Len(t, beef.BUMPs, 1)
+	require.Len(t, beef.Transactions(), 2)
+
+	subjects := beef.Subjects()
+	require.Len(t, subjects, 1)
+	subject := subjects[0]
+	parentTxid := subject.Inputs[0].SourceTXID.String()
+
+	require.Equal(t, subject, beef.FindTransaction(subject.TxID().String()))
+	require.Nil(t, beef.FindBump(subject.TxID().String()))
+	require.Equal(t, subject.Inputs[0].SourceTransaction, beef.FindTransaction(parentTxid))
+	require.Equal(t, beef.BUMPs[0], beef.FindBump(parentTxid))
+	require.Nil(t, beef.FindTransaction("00"))
+
+	out, err := beef.Hex()
+	require.NoError(t, err)
+	require.Equal(t, BRC62Hex, out)
+}
+
+func TestBeefMerge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges several subjects sharing an ancestor", func(t *testing.T) {
+		beef, err := transaction.NewBeefFromHex(BRC62Hex)
+		require.NoError(t, err)
+		parent := beef.Subjects()[0].Inputs[0].SourceTransaction
+
+		other := transaction.NewTransaction()
+		other.AddInputFromTx(parent, 0, nil)
+		other.AddOutput(&transaction.TransactionOutput{
+			Satoshis:      1,
+			LockingScript: parent.Outputs[0].LockingScript,
+		})
+		otherBeef := transaction.NewBeef()
+		require.NoError(t, otherBeef.MergeTransaction(other))
+		require.Len(t, otherBeef.Transactions(), 2)
+
+		require.NoError(t, beef.MergeBeef(otherBeef))
+		require.Len(t, beef.BUMPs, 1)
+		require.Len(t, beef.Transactions(), 3)
+		require.Len(t, beef.Subjects(), 2)
+
+		bytes, err := beef.Bytes()
+		require.NoError(t, err)
+		parsed, err := transaction.NewBeefFromBytes(bytes)
+		require.NoError(t, err)
+		require.Len(t, parsed.Transactions(), 3)
+		require.Len(t, parsed.Subjects(), 2)
+	})
+
+	t.Run("rejects transactions without ancestry", func(t *testing.T) {
+		beef, err := transaction.NewBeefFromHex(BRC62Hex)
+		require.NoError(t, err)
+		subject := beef.Subjects()[0]
+		orphan := transaction.NewTransaction()
+		orphan.AddInput(&transaction.TransactionInput{
+			SourceTXID:       subject.Inputs[0].SourceTransaction.Inputs[0].SourceTXID,
+			SourceTxOutIndex: 0,
+		})
+
+		require.ErrorIs(t, transaction.NewBeef().MergeTransaction(orphan), transaction.ErrBeefMissingSourceTx)
+	})
+
+	t.Run("proves merged transactions with the merged BUMPs", func(t *testing.T) {
+		other, err := transaction.NewBeefFromHex(BRC62Hex)
+		require.NoError(t, err)
+		parentTxid := other.Subjects()[0].Inputs[0].SourceTXID.String()
+
+		beef := transaction.NewBeef()
+		require.NoError(t, beef.MergeBeef(other))
+		require.Same(t, beef.FindBump(parentTxid), beef.FindTransaction(parentTxid).MerklePath)
+		require.NotSame(t, other.BUMPs[0], beef.BUMPs[0])
+	})
+
+	t.Run("combines BUMPs without changing shared ones", func(t *testing.T) {
+		txs := make([]*transaction.Transaction, 2)
+		for i := range txs {
+			txs[i] = transaction.NewTransaction()
+			txs[i].AddOutput(&transaction.TransactionOutput{
+				Satoshis:      uint64(i + 1),
+				LockingScript: &script.Script{script.OpTRUE},
+			})
+		}
+		// proof returns the path of the block of both transactions proving
+		// the i-th.
+		proof := func(i int) *transaction.MerklePath {
+			isTxid := true
+			leaves := []*transaction.PathElement{{Offset: 0, Hash: txs[0].TxID()}, {Offset: 1, Hash: txs[1].TxID()}}
+			leaves[i].Txid = &isTxid
+			return transaction.NewMerklePath(1, [][]*transaction.PathElement{leaves})
+		}
+
+		txs[0].MerklePath = proof(0)
+		beef := transaction.NewBeef()
+		require.NoError(t, beef.MergeTransaction(txs[0]))
+		b, err := beef.Bytes()
+		require.NoError(t, err)
+		beef, err = transaction.NewBeefFromBytes(b)
+		require.NoError(t, err)
+		shared := beef.FindTransaction(txs[0].TxID().String()).MerklePath
+		require.Same(t, beef.BUMPs[0], shared)
+
+		txs[1].MerklePath = proof(1)
+		require.NoError(t, beef.MergeTransaction(txs[1]))
+		require.Len(t, beef.BUMPs, 1)
+		require.Nil(t, shared.Path[0][1].Txid)
+		require.Same(t, beef.BUMPs[0], beef.FindTransaction(txs[0].TxID().String()).MerklePath)
+		for _, tx := range txs {
+			require.Same(t, beef.BUMPs[0], beef.FindBump(tx.TxID().String()))
+		}
+	})
+}
+
+func TestBeefTrim(t *testing.T) {
+	t.Parallel()
+
+	beef, err := transaction.NewBeefFromHex(BRC62Hex)
+	require.NoError(t, err)
+	lockingScript := beef.Subjects()[0].Outputs[0].LockingScript
+
+	grandparent := transaction.NewTransaction()
+	grandparent.AddOutput(&transaction.TransactionOutput{Satoshis: 2, LockingScript: lockingScript})
+	parent := transaction.NewTransaction()
+	parent.AddInputFromTx(grandparent, 0, nil)
+	parent.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: lockingScript})
+
+	beef = transaction.NewBeef()
+	require.NoError(t, beef.MergeTransaction(parent))
+	require.Len(t, beef.Transactions(), 2)
+	require.Nil(t, beef.FindBump(parent.TxID().String()))
+
+	isTxid := true
+	bumpIndex, err := beef.MergeBump(transaction.NewMerklePath(1, [][]*transaction.PathElement{
+		{{Offset: 0, Hash: parent.TxID(), Txid: &isTxid}},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, 0, bumpIndex)
+	require.NotNil(t, beef.FindBump(parent.TxID().String()))
+
+	beef.Trim()
+	require.Len(t, beef.Transactions(), 1)
+	require.Nil(t, beef.FindTransaction(grandparent.TxID().String()))
+	require.NotNil(t, beef.FindTransaction(parent.TxID().String()))
+	require.Len(t, beef.BUMPs, 1)
+}
+
+func TestBeefV2(t *testing.T) {
+	t.Parallel()
+
+	t.Run("converts between V1 and V2", func(t *testing.T) {
+		beef, err := transaction.NewBeefFromHex(BRC62Hex)
+		require.NoError(t, err)
+
+		beef.Version = transaction.BeefV2
+		v2, err := beef.Bytes()
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x02, 0x00, 0xbe, 0xef}, v2[:4])
+
+		parsed, err := transaction.NewBeefFromBytes(v2)
+		require.NoError(t, err)
+		require.Equal(t, transaction.BeefV2, parsed.Version)
+		require.Len(t, parsed.Transactions(), 2)
+		require.NotNil(t, parsed.Subjects()[0].Inputs[0].SourceTransaction.MerklePath)
+
+		parsed.Version = transaction.BeefV1
+		v1, err := parsed.Hex()
+		require.NoError(t, err)
+		require.Equal(t, BRC62Hex, v1)
+	})
+
+	t.Run("holds txid only transactions", func(t *testing.T) {
+		beef, err := transaction.NewBeefFromHex(BRC62Hex)
+		require.NoError(t, err)
+		subject := beef.Subjects()[0]
+		parentTxid := subject.Inputs[0].SourceTXID
+
+		// The subject's parent is known elsewhere, so only its txid is sent.
+		txidOnly := transaction.NewBeef()
+		txidOnly.Version = transaction.BeefV2
+		txidOnly.MergeTxidOnly(parentTxid)
+		require.NoError(t, txidOnly.MergeTransaction(subject))
+		require.Len(t, txidOnly.Transactions(), 2)
+
+		v2, err := txidOnly.Bytes()
+		require.NoError(t, err)
+		parsed, err := transaction.NewBeefFromBytes(v2)
+		require.NoError(t, err)
+		require.Len(t, parsed.Transactions(), 2)
+		require.Nil(t, parsed.FindTransaction(parentTxid.String()))
+		require.Equal(t, parentTxid, parsed.Transactions()[0].TxID())
+		require.NotNil(t, parsed.FindTransaction(subject.TxID().String()))
+
+		parsed.Version = transaction.BeefV1
+		_, err = parsed.Bytes()
+		require.Error(t, err)
+
+		// Merging the full ancestry replaces the txid only entry.
+		require.NoError(t, parsed.MergeBeef(beef))
+		require.NotNil(t, parsed.FindTransaction(parentTxid.String()))
+		require.NotNil(t, parsed.Subjects()[0].Inputs[0].SourceTransaction)
+		v1, err := parsed.Hex()
+		require.NoError(t, err)
+		require.Equal(t, BRC62Hex, v1)
+	})
+}
+
+func TestAtomicBeef(t *testing.T) {
+	t.Parallel()
+
+	tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+	require.NoError(t, err)
+
+	atomic, err := tx.AtomicBEEF()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x01, 0x01, 0x01}, atomic[:4])
+	require.Equal(t, tx.TxID().CloneBytes(), atomic[4:36])
+
+	beef, err := transaction.NewBeefFromBytes(atomic)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxID(), beef.AtomicTxID)
+
+	subject, err := transaction.NewTransactionFromBEEF(atomic)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxID(), subject.TxID())
+
+	// Converting back to a plain BEEF drops the prefix.
+	beef.AtomicTxID = nil
+	v1, err := beef.Hex()
+	require.NoError(t, err)
+	require.Equal(t, BRC62Hex, v1)
+
+	// Only the ancestry of the subject is kept.
+	parent := tx.Inputs[0].SourceTransaction
+	atomic, err = beef.AtomicBytes(parent.TxID())
+	require.NoError(t, err)
+	parentBeef, err := transaction.NewBeefFromBytes(atomic)
+	require.NoError(t, err)
+	require.Len(t, parentBeef.Transactions(), 1)
+	require.Equal(t, parent.TxID(), parentBeef.AtomicTxID)
+
+	_, err = beef.AtomicBytes(beef.BUMPs[0].Path[0][0].Hash)
+	require.Error(t, err)
+}
+
+func TestBeefValidate(t *testing.T) {
+	t.Parallel()
+
+	beef, err := transaction.NewBeefFromHex(BRC62Hex)
+	require.NoError(t, err)
+	require.NoError(t, beef.Validate())
+	child := beef.Subjects()[0]
+	parent := child.Inputs[0].SourceTransaction
+	bump := beef.BUMPs[0]
+
+	isTxid := true
+	otherBump := transaction.NewMerklePath(bump.BlockHeight, [][]*transaction.PathElement{
+		{{Offset: 0, Hash: child.TxID(), Txid: &isTxid}},
+	})
+
+	// rawBeef writes a BEEF V1 with the given BUMPs and transactions, each
+	// transaction followed by its BUMP index or nothing when it is negative.
+	type entry struct {
+		tx        *transaction.Transaction
+		bumpIndex int
+	}
+	rawBeef := func(bumps []*transaction.MerklePath, entries ...entry) []byte {
+		raw := []byte{0x01, 0x00, 0xbe, 0xef}
+		raw = append(raw, transaction.VarInt(len(bumps)).Bytes()...)
+		for _, mp := range bumps {
+			raw = append(raw, mp.Bytes()...)
+		}
+		raw = append(raw, transaction.VarInt(len(entries)).Bytes()...)
+		for _, e := range entries {
+			raw = append(raw, e.tx.Bytes()...)
+			if e.bumpIndex < 0 {
+				raw = append(raw, 0)
+			} else {
+				raw = append(raw, 1)
+				raw = append(raw, transaction.VarInt(e.bumpIndex).Bytes()...)
+			}
+		}
+		return raw
+	}
+
+	valid := rawBeef([]*transaction.MerklePath{bump}, entry{parent, 0}, entry{child, -1})
+	_, err = transaction.NewBeefFromBytes(valid)
+	require.NoError(t, err)
+
+	v2 := slices.Clone(valid)
+	v2[0] = 0x02
+	v2[4+len(transaction.VarInt(1).Bytes())+len(bump.Bytes())+1] = 0x03
+
+	tests := map[string]struct {
+		beef []byte
+		err  error
+	}{
+		"unknown version": {
+			beef: append([]byte{0x03, 0x00, 0xbe, 0xef}, valid[4:]...),
+			err:  transaction.ErrBeefVersion,
+		},
+		"unknown transaction format": {
+			beef: v2,
+			err:  transaction.ErrBeefTxFormat,
+		},
+		"trailing bytes": {
+			beef: append(slices.Clone(valid), 0x00),
+			err:  transaction.ErrBeefTrailingBytes,
+		},
+		"BUMP index out of range": {
+			beef: rawBeef([]*transaction.MerklePath{bump}, entry{parent, 1}, entry{child, -1}),
+			err:  transaction.ErrBeefBumpIndex,
+		},
+		"duplicate transaction": {
+			beef: rawBeef([]*transaction.MerklePath{bump}, entry{parent, 0}, entry{parent, 0}, entry{child, -1}),
+			err:  transaction.ErrBeefDuplicateTx,
+		},
+		"child before parent": {
+			beef: rawBeef([]*transaction.MerklePath{bump}, entry{child, -1}, entry{parent, 0}),
+			err:  transaction.ErrBeefTxOrder,
+		},
+		"unproven transaction spending an unknown transaction": {
+			beef: rawBeef(nil, entry{child, -1}),
+			err:  transaction.ErrBeefUnknownSource,
+		},
+		"BUMP not proving the transaction": {
+			beef: rawBeef([]*transaction.MerklePath{otherBump}, entry{parent, 0}, entry{child, -1}),
+			err:  transaction.ErrBeefBumpMissingTx,
+		},
+		"BUMPs at the same height with different roots": {
+			beef: rawBeef([]*transaction.MerklePath{bump, otherBump}, entry{parent, 0}, entry{child, -1}),
+			err:  transaction.ErrBeefBumpRootMismatch,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := transaction.NewBeefFromBytes(tc.beef)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("truncated BEEF does not panic", func(t *testing.T) {
+		for i := 0; i < len(valid); i++ {
+			_, err := transaction.NewBeefFromBytes(valid[:i])
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestBeefFromReader(t *testing.T) {
+	t.Parallel()
+
+	raw, err := hex.DecodeString(BRC62Hex)
+	require.NoError(t, err)
+
+	t.Run("reads a BEEF and stops at its end", func(t *testing.T) {
+		r := bytes.NewReader(append(slices.Clone(raw), 0xaa))
+		beef, err := transaction.NewBeefFromReader(r, transaction.BeefLimits{
+			MaxBytes:        int64(len(raw)),
+			MaxBUMPs:        1,
+			MaxTransactions: 2,
+		})
+		require.NoError(t, err)
+		require.Len(t, beef.Transactions(), 2)
+		require.Equal(t, 1, r.Len())
+
+		var buf bytes.Buffer
+		n, err := beef.WriteTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(raw)), n)
+		require.Equal(t, raw, buf.Bytes())
+	})
+
+	t.Run("returns the subject transaction", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFReader(bytes.NewReader(raw), transaction.BeefLimits{})
+		require.NoError(t, err)
+		require.NotNil(t, tx.Inputs[0].SourceTransaction)
+	})
+
+	limits := map[string]transaction.BeefLimits{
+		"too many bytes":        {MaxBytes: int64(len(raw)) - 1},
+		"too many transactions": {MaxTransactions: 1},
+	}
+	for name, limit := range limits {
+		t.Run(name, func(t *testing.T) {
+			_, err := transaction.NewBeefFromReader(bytes.NewReader(raw), limit)
+			require.ErrorIs(t, err, transaction.ErrBeefLimitExceeded)
+		})
+	}
+}
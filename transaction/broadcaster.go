@@ -1,5 +1,7 @@
 package transaction
 
+import "context"
+
 type BroadcastSuccess struct {
 	Txid    string `json:"txid"`
 	Message string `json:"message"`
@@ -18,6 +20,29 @@ type Broadcaster interface {
 	Broadcast(tx *Transaction) (*BroadcastSuccess, *BroadcastFailure)
 }
 
+// ContextBroadcaster is a Broadcaster which can be given a context, whose
+// cancellation or deadline ends the broadcast.
+type ContextBroadcaster interface {
+	Broadcaster
+	BroadcastContext(ctx context.Context, tx *Transaction) (*BroadcastSuccess, *BroadcastFailure)
+}
+
 func (t *Transaction) Broadcast(b Broadcaster) (*BroadcastSuccess, *BroadcastFailure) {
 	return b.Broadcast(t)
 }
+
+// BroadcastContext broadcasts the transaction with b, passing it ctx when
+// it is a ContextBroadcaster. Other broadcasters are only checked against
+// ctx before broadcasting.
+func (t *Transaction) BroadcastContext(ctx context.Context, b Broadcaster) (*BroadcastSuccess, *BroadcastFailure) {
+	if cb, ok := b.(ContextBroadcaster); ok {
+		return cb.BroadcastContext(ctx, t)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
+		}
+	}
+	return b.Broadcast(t)
+}
@@ -4,29 +4,52 @@ import (
 	"bytes"
 	"context"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bitcoin-sv/go-sdk/transaction"
 )
 
+// ArcStatus is the status of a transaction in ARC.
 type ArcStatus string
 
 const (
-	RECEIVED             ArcStatus = "2"
-	STORED               ArcStatus = "3"
-	ANNOUNCED_TO_NETWORK ArcStatus = "4"
-	REQUESTED_BY_NETWORK ArcStatus = "5"
-	SENT_TO_NETWORK      ArcStatus = "6"
-	ACCEPTED_BY_NETWORK  ArcStatus = "7"
-	SEEN_ON_NETWORK      ArcStatus = "8"
+	UNKNOWN                ArcStatus = "UNKNOWN"
+	QUEUED                 ArcStatus = "QUEUED"
+	RECEIVED               ArcStatus = "RECEIVED"
+	STORED                 ArcStatus = "STORED"
+	ANNOUNCED_TO_NETWORK   ArcStatus = "ANNOUNCED_TO_NETWORK"
+	REQUESTED_BY_NETWORK   ArcStatus = "REQUESTED_BY_NETWORK"
+	SENT_TO_NETWORK        ArcStatus = "SENT_TO_NETWORK"
+	ACCEPTED_BY_NETWORK    ArcStatus = "ACCEPTED_BY_NETWORK"
+	SEEN_IN_ORPHAN_MEMPOOL ArcStatus = "SEEN_IN_ORPHAN_MEMPOOL"
+	SEEN_ON_NETWORK        ArcStatus = "SEEN_ON_NETWORK"
+	DOUBLE_SPEND_ATTEMPTED ArcStatus = "DOUBLE_SPEND_ATTEMPTED"
+	REJECTED               ArcStatus = "REJECTED"
+	MINED                  ArcStatus = "MINED"
 )
 
+// legacyStatusCodes are the codes of the X-WaitForStatus header understood
+// by ARC instances predating the X-WaitFor header.
+var legacyStatusCodes = map[ArcStatus]string{
+	RECEIVED:             "2",
+	STORED:               "3",
+	ANNOUNCED_TO_NETWORK: "4",
+	REQUESTED_BY_NETWORK: "5",
+	SENT_TO_NETWORK:      "6",
+	ACCEPTED_BY_NETWORK:  "7",
+	SEEN_ON_NETWORK:      "8",
+}
+
 type Arc struct {
+	// ApiUrl is the url of the ARC API including its version, such as
+	// https://arc.gorillapool.io/v1.
 	ApiUrl               string
 	ApiKey               string
 	CallbackUrl          *string
@@ -37,19 +60,29 @@ type Arc struct {
 	SkipScriptValidation bool
 	SkipTxValidation     bool
 	WaitForStatus        ArcStatus
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+	// Retry configures retrying requests while ARC is unavailable. Zero
+	// disables retries.
+	Retry RetryPolicy
+	// Format forces the serialization of the transactions sent, chosen
+	// from the data attached to them by default.
+	Format TxFormat
 }
 
 type ArcResponse struct {
-	BlockHash   string     `json:"blockHash,omitempty"`
-	BlockHeight uint32     `json:"blockHeight,omitempty"`
-	ExtraInfo   string     `json:"extraInfo,omitempty"`
-	Status      int        `json:"status,omitempty"`
-	Timestamp   time.Time  `json:"timestamp,omitempty"`
-	Title       string     `json:"title,omitempty"`
-	TxStatus    *ArcStatus `json:"txStatus,omitempty"`
-	Instance    *string    `json:"instance,omitempty"`
-	Txid        string     `json:"txid,omitempty"`
-	Detail      *string    `json:"detail,omitempty"`
+	BlockHash    string     `json:"blockHash,omitempty"`
+	BlockHeight  uint32     `json:"blockHeight,omitempty"`
+	ExtraInfo    string     `json:"extraInfo,omitempty"`
+	Status       int        `json:"status,omitempty"`
+	Timestamp    time.Time  `json:"timestamp,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	TxStatus     *ArcStatus `json:"txStatus,omitempty"`
+	Instance     *string    `json:"instance,omitempty"`
+	Txid         string     `json:"txid,omitempty"`
+	Detail       *string    `json:"detail,omitempty"`
+	MerklePath   string     `json:"merklePath,omitempty"`
+	CompetingTxs []string   `json:"competingTxs,omitempty"`
 }
 
 func (ts ArcResponse) Value() (driver.Value, error) {
@@ -64,44 +97,313 @@ func (f *ArcResponse) Scan(value interface{}) error {
 	return json.Unmarshal(b, &f)
 }
 
+// ParseMerklePath parses the BUMP hex of a mined transaction. It returns
+// nil when the response holds no merkle path.
+func (f *ArcResponse) ParseMerklePath() (*transaction.MerklePath, error) {
+	if f.MerklePath == "" {
+		return nil, nil
+	}
+	return transaction.NewMerklePathFromHex(f.MerklePath)
+}
+
+// ArcError is an error response of ARC.
+type ArcError struct {
+	Status    int    `json:"status"`
+	Title     string `json:"title,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	ExtraInfo string `json:"extraInfo,omitempty"`
+	Txid      string `json:"txid,omitempty"`
+}
+
+func (e *ArcError) Error() string {
+	msg := fmt.Sprintf("arc responded with status %d", e.Status)
+	if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// temporary reports whether the error is worth retrying.
+func (e *ArcError) temporary() bool {
+	return e.Status >= 500
+}
+
+// ArcFee is a fee rate of Satoshis per Bytes.
+type ArcFee struct {
+	Satoshis uint64 `json:"satoshis"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+// ArcPolicy is the policy ARC applies to the transactions it accepts.
+type ArcPolicy struct {
+	MaxScriptSizePolicy     uint64 `json:"maxscriptsizepolicy"`
+	MaxTxSigOpsCountsPolicy uint64 `json:"maxtxsigopscountspolicy"`
+	MaxTxSizePolicy         uint64 `json:"maxtxsizepolicy"`
+	MiningFee               ArcFee `json:"miningFee"`
+}
+
+type ArcPolicyResponse struct {
+	Policy    ArcPolicy `json:"policy"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type arcRawTx struct {
+	RawTx string `json:"rawTx"`
+}
+
 func (a *Arc) Broadcast(t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
-	var buf *bytes.Buffer
-	for _, input := range t.Inputs {
-		if input.SourceTxOutput() == nil {
-			buf = bytes.NewBuffer(t.Bytes())
-			break
+	return a.BroadcastContext(context.Background(), t)
+}
+
+// BroadcastContext broadcasts the transaction, retrying as configured by
+// Retry while ARC is unavailable, until ctx is done.
+func (a *Arc) BroadcastContext(ctx context.Context, t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	body, err := a.txBytes(t)
+	if err != nil {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
 		}
 	}
-	if buf == nil {
-		if ef, err := t.EF(); err != nil {
+
+	response := &ArcResponse{}
+	if err := a.call(ctx, "POST", "/tx", "application/octet-stream", body, response); err != nil {
+		var arcErr *ArcError
+		if errors.As(err, &arcErr) {
 			return nil, &transaction.BroadcastFailure{
-				Code:        "500",
-				Description: err.Error(),
+				Code:        fmt.Sprintf("%d", arcErr.Status),
+				Description: describe(arcErr.Title, arcErr.Detail, arcErr.ExtraInfo),
 			}
-		} else {
-			buf = bytes.NewBuffer(ef)
 		}
-	}
-
-	ctx := context.Background()
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		a.ApiUrl+"/tx",
-		buf,
-	)
-	if err != nil {
 		return nil, &transaction.BroadcastFailure{
 			Code:        "500",
 			Description: err.Error(),
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/octet-stream")
+	if response.Status == 200 {
+		return &transaction.BroadcastSuccess{
+			Txid:    response.Txid,
+			Message: response.Title,
+		}, nil
+	}
+
+	var detail string
+	if response.Detail != nil {
+		detail = *response.Detail
+	}
+	return nil, &transaction.BroadcastFailure{
+		Code:        fmt.Sprintf("%d", response.Status),
+		Description: describe(response.Title, detail, response.ExtraInfo),
+	}
+}
+
+// describe joins the title, detail and extra info of an ARC error, where
+// the reason of a rejection is usually found.
+func describe(parts ...string) string {
+	description := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			description = append(description, part)
+		}
+	}
+	return strings.Join(description, ": ")
+}
+
+// BroadcastMany broadcasts the transactions in a single request, returning
+// the response of ARC for each of them in the same order. Rejections of
+// some of the transactions are reported in their response; an error means
+// the whole batch failed.
+func (a *Arc) BroadcastMany(ctx context.Context, txs []*transaction.Transaction) ([]*ArcResponse, error) {
+	rawTxs := make([]arcRawTx, 0, len(txs))
+	for _, t := range txs {
+		b, err := a.txBytes(t)
+		if err != nil {
+			return nil, err
+		}
+		rawTxs = append(rawTxs, arcRawTx{RawTx: hex.EncodeToString(b)})
+	}
+	body, err := json.Marshal(rawTxs)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*ArcResponse
+	if err := a.call(ctx, "POST", "/txs", "application/json", body, &responses); err != nil {
+		return nil, err
+	}
+	if len(responses) != len(txs) {
+		return nil, fmt.Errorf("arc returned %d responses for %d transactions", len(responses), len(txs))
+	}
+	return responses, nil
+}
+
+// GetStatus returns the status of a transaction known to ARC. A transaction
+// unknown to ARC results in an *ArcError with status 404.
+func (a *Arc) GetStatus(ctx context.Context, txid string) (*ArcResponse, error) {
+	response := &ArcResponse{}
+	if err := a.call(ctx, "GET", "/tx/"+txid, "", nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetPolicy returns the policy of ARC, including its mining fee.
+func (a *Arc) GetPolicy(ctx context.Context) (*ArcPolicyResponse, error) {
+	response := &ArcPolicyResponse{}
+	if err := a.call(ctx, "GET", "/policy", "", nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
 
+// TxFormat is the serialization of the transactions sent to ARC.
+type TxFormat int
+
+const (
+	// FormatAuto sends BEEF when unproven ancestors of the transaction are
+	// attached to its inputs, Extended Format when the outputs it spends
+	// are known, and the raw transaction otherwise.
+	FormatAuto TxFormat = iota
+	// FormatBEEF sends the transaction along with its ancestry (BRC-62),
+	// which ARC needs to accept a chain of unconfirmed transactions.
+	FormatBEEF
+	// FormatEF sends the transaction in Extended Format (BRC-30), holding
+	// the outputs it spends so that ARC validates it without looking them
+	// up.
+	FormatEF
+	// FormatRaw sends the raw transaction.
+	FormatRaw
+)
+
+// txBytes serializes the transaction according to Format.
+func (a *Arc) txBytes(t *transaction.Transaction) ([]byte, error) {
+	switch a.Format {
+	case FormatAuto:
+	case FormatBEEF:
+		return t.BEEF()
+	case FormatEF:
+		return t.EF()
+	case FormatRaw:
+		return t.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown transaction format %d", a.Format)
+	}
+
+	if hasUnprovenParent(t) && hasFullAncestry(t, make(map[*transaction.Transaction]bool)) {
+		if beef, err := t.BEEF(); err == nil {
+			return beef, nil
+		}
+	}
+	for _, input := range t.Inputs {
+		if input.SourceTxOutput() == nil {
+			return t.Bytes(), nil
+		}
+	}
+	if ef, err := t.EF(); err == nil {
+		return ef, nil
+	}
+	return t.Bytes(), nil
+}
+
+// hasUnprovenParent reports whether an input of the transaction spends an
+// attached transaction lacking a merkle path.
+func hasUnprovenParent(t *transaction.Transaction) bool {
+	for _, input := range t.Inputs {
+		if input.SourceTransaction != nil && input.SourceTransaction.MerklePath == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFullAncestry reports whether every input of the transaction spends an
+// attached transaction which is either proven by a merkle path or itself
+// has a full ancestry, so that a BEEF of the transaction can be verified.
+func hasFullAncestry(t *transaction.Transaction, checked map[*transaction.Transaction]bool) bool {
+	if full, ok := checked[t]; ok {
+		return full
+	}
+	full := true
+	for _, input := range t.Inputs {
+		source := input.SourceTransaction
+		if source == nil || (source.MerklePath == nil && !hasFullAncestry(source, checked)) {
+			full = false
+			break
+		}
+	}
+	checked[t] = full
+	return full
+}
+
+// call sends a request to the endpoint, retrying as configured by Retry,
+// and decodes the response into v. Error responses are returned as an
+// *ArcError.
+func (a *Arc) call(ctx context.Context, method string, endpoint string, contentType string, body []byte, v any) error {
+	return a.Retry.do(ctx, func() error {
+		return a.send(ctx, method, endpoint, contentType, body, v)
+	})
+}
+
+// send sends a request once.
+func (a *Arc) send(ctx context.Context, method string, endpoint string, contentType string, body []byte, v any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.ApiUrl+endpoint, reader)
+	if err != nil {
+		return permanent(err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
 	if a.ApiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+a.ApiKey)
 	}
+	if method == "POST" {
+		a.setBroadcastHeaders(req)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		arcErr := &ArcError{}
+		if err := json.Unmarshal(msg, arcErr); err != nil || arcErr.Title == "" {
+			arcErr.Title = strings.TrimSpace(string(msg))
+		}
+		arcErr.Status = resp.StatusCode
+		if !arcErr.temporary() {
+			return permanent(arcErr)
+		}
+		return arcErr
+	}
+
+	if err := json.Unmarshal(msg, v); err != nil {
+		return permanent(err)
+	}
+	return nil
+}
+
+func (a *Arc) setBroadcastHeaders(req *http.Request) {
 	if a.CallbackUrl != nil {
 		req.Header.Set("X-CallbackUrl", *a.CallbackUrl)
 	}
@@ -124,49 +426,9 @@ func (a *Arc) Broadcast(t *transaction.Transaction) (*transaction.BroadcastSucce
 		req.Header.Set("X-SkipTxValidation", "true")
 	}
 	if a.WaitForStatus != "" {
-		req.Header.Set("X-WaitForStatus", string(a.WaitForStatus))
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, &transaction.BroadcastFailure{
-			Code:        "500",
-			Description: err.Error(),
+		req.Header.Set("X-WaitFor", string(a.WaitForStatus))
+		if code, ok := legacyStatusCodes[a.WaitForStatus]; ok {
+			req.Header.Set("X-WaitForStatus", code)
 		}
 	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			// Handle or log the error if needed
-			// For example:
-			fmt.Println(cerr)
-		}
-	}()
-	msg, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, &transaction.BroadcastFailure{
-			Code:        "500",
-			Description: err.Error(),
-		}
-	}
-
-	response := &ArcResponse{}
-	err = json.Unmarshal(msg, &response)
-	if err != nil {
-		return nil, &transaction.BroadcastFailure{
-			Code:        "500",
-			Description: err.Error(),
-		}
-	}
-
-	if response.Status == 200 {
-		return &transaction.BroadcastSuccess{
-			Txid:    response.Txid,
-			Message: response.Title,
-		}, nil
-	}
-
-	return nil, &transaction.BroadcastFailure{
-		Code:        fmt.Sprintf("%d", response.Status),
-		Description: response.Title,
-	}
 }
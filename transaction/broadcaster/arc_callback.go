@@ -0,0 +1,138 @@
+package broadcaster
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+// maxArcCallbackSize bounds the size of a callback request, batches
+// included.
+const maxArcCallbackSize = 32 << 20
+
+// ArcCallback is a transaction status update sent by ARC to the CallbackUrl
+// of a broadcast.
+type ArcCallback struct {
+	Txid         string
+	TxStatus     ArcStatus
+	Timestamp    time.Time
+	ExtraInfo    string
+	CompetingTxs []string
+	BlockHash    string
+	BlockHeight  uint32
+	// MerklePath proves the transaction once it is mined, nil otherwise.
+	MerklePath *transaction.MerklePath
+}
+
+// ArcCallbackHandler is an http.Handler receiving the callbacks of ARC,
+// whether sent one by one or in batches.
+//
+// Callbacks are passed to OnCallback when set, then sent to Events when
+// set. A callback failing to be handled, because OnCallback returned an
+// error or the request ended before Events received it, is answered with an
+// error status so that ARC sends it again.
+type ArcCallbackHandler struct {
+	// Token is the CallbackToken given to ARC, expected as a bearer token.
+	// Callbacks are not authenticated when it is empty.
+	Token      string
+	OnCallback func(ctx context.Context, callback *ArcCallback) error
+	Events     chan<- *ArcCallback
+}
+
+type arcCallbackBatch struct {
+	Count     int            `json:"count"`
+	Callbacks []*ArcResponse `json:"callbacks"`
+}
+
+func (h *ArcCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" {
+		token := []byte("Bearer " + h.Token)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), token) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxArcCallbackSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	callbacks, err := ParseArcCallbacks(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	for _, callback := range callbacks {
+		if h.OnCallback != nil {
+			if err := h.OnCallback(ctx, callback); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if h.Events != nil {
+			select {
+			case h.Events <- callback:
+			case <-ctx.Done():
+				http.Error(w, ctx.Err().Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ParseArcCallbacks decodes the body of an ARC callback request, holding a
+// single callback or a batch of them.
+func ParseArcCallbacks(body []byte) ([]*ArcCallback, error) {
+	batch := &arcCallbackBatch{}
+	if err := json.Unmarshal(body, batch); err != nil {
+		return nil, err
+	}
+	responses := batch.Callbacks
+	if responses == nil {
+		response := &ArcResponse{}
+		if err := json.Unmarshal(body, response); err != nil {
+			return nil, err
+		}
+		responses = []*ArcResponse{response}
+	}
+
+	callbacks := make([]*ArcCallback, 0, len(responses))
+	for _, response := range responses {
+		if response == nil || response.Txid == "" {
+			return nil, fmt.Errorf("arc callback without txid")
+		}
+		merklePath, err := response.ParseMerklePath()
+		if err != nil {
+			return nil, fmt.Errorf("arc callback for %s: invalid merkle path: %w", response.Txid, err)
+		}
+		callback := &ArcCallback{
+			Txid:         response.Txid,
+			Timestamp:    response.Timestamp,
+			ExtraInfo:    response.ExtraInfo,
+			CompetingTxs: response.CompetingTxs,
+			BlockHash:    response.BlockHash,
+			BlockHeight:  response.BlockHeight,
+			MerklePath:   merklePath,
+		}
+		if response.TxStatus != nil {
+			callback.TxStatus = *response.TxStatus
+		}
+		callbacks = append(callbacks, callback)
+	}
+	return callbacks, nil
+}
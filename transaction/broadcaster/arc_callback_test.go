@@ -0,0 +1,97 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"github.com/stretchr/testify/require"
+)
+
+func postCallback(handler http.Handler, token string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestArcCallbackHandler(t *testing.T) {
+	txid := newTestTx().TxID()
+	path, err := transaction.NewMerklePathFromBlockTxids(850000, []*chainhash.Hash{txid}, txid)
+	require.NoError(t, err)
+	mined := `{"timestamp":"2024-06-01T12:00:00Z","txid":"` + txid.String() + `","txStatus":"MINED","blockHash":"00","blockHeight":850000,"merklePath":"` + path.Hex() + `"}`
+	seen := `{"timestamp":"2024-06-01T11:50:00Z","txid":"` + txid.String() + `","txStatus":"SEEN_ON_NETWORK"}`
+
+	t.Run("dispatches a callback", func(t *testing.T) {
+		var callbacks []*broadcaster.ArcCallback
+		handler := &broadcaster.ArcCallbackHandler{
+			Token: "token",
+			OnCallback: func(ctx context.Context, callback *broadcaster.ArcCallback) error {
+				callbacks = append(callbacks, callback)
+				return nil
+			},
+		}
+		rec := postCallback(handler, "token", mined)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, callbacks, 1)
+		require.Equal(t, txid.String(), callbacks[0].Txid)
+		require.Equal(t, broadcaster.MINED, callbacks[0].TxStatus)
+		require.Equal(t, uint32(850000), callbacks[0].BlockHeight)
+		require.Equal(t, path.Hex(), callbacks[0].MerklePath.Hex())
+
+		root, err := callbacks[0].MerklePath.ComputeRoot(txid)
+		require.NoError(t, err)
+		require.Equal(t, txid, root)
+	})
+
+	t.Run("sends batched callbacks to a channel", func(t *testing.T) {
+		events := make(chan *broadcaster.ArcCallback, 2)
+		handler := &broadcaster.ArcCallbackHandler{Events: events}
+		rec := postCallback(handler, "", `{"count":2,"callbacks":[`+seen+`,`+mined+`]}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		first, second := <-events, <-events
+		require.Equal(t, broadcaster.SEEN_ON_NETWORK, first.TxStatus)
+		require.Nil(t, first.MerklePath)
+		require.Equal(t, broadcaster.MINED, second.TxStatus)
+		require.NotNil(t, second.MerklePath)
+	})
+
+	t.Run("rejects a wrong token", func(t *testing.T) {
+		handler := &broadcaster.ArcCallbackHandler{Token: "token", Events: make(chan *broadcaster.ArcCallback, 1)}
+		require.Equal(t, http.StatusUnauthorized, postCallback(handler, "", seen).Code)
+		require.Equal(t, http.StatusUnauthorized, postCallback(handler, "other", seen).Code)
+	})
+
+	t.Run("rejects malformed callbacks", func(t *testing.T) {
+		handler := &broadcaster.ArcCallbackHandler{Events: make(chan *broadcaster.ArcCallback, 1)}
+		require.Equal(t, http.StatusBadRequest, postCallback(handler, "", `{"txid":`).Code)
+		require.Equal(t, http.StatusBadRequest, postCallback(handler, "", `{"txStatus":"MINED"}`).Code)
+		require.Equal(t, http.StatusBadRequest, postCallback(handler, "", `{"txid":"`+txid.String()+`","merklePath":"fe"}`).Code)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		handler := &broadcaster.ArcCallbackHandler{}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("asks for callbacks failing to be handled again", func(t *testing.T) {
+		handler := &broadcaster.ArcCallbackHandler{
+			OnCallback: func(ctx context.Context, callback *broadcaster.ArcCallback) error {
+				return errors.New("database unavailable")
+			},
+		}
+		require.Equal(t, http.StatusInternalServerError, postCallback(handler, "", mined).Code)
+	})
+}
@@ -0,0 +1,330 @@
+package broadcaster_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTx() *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: &script.Script{script.OpTRUE}})
+	return tx
+}
+
+// arcServer answers the n-th broadcast with the n-th status, repeating the
+// last one, and counts the requests.
+func arcServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/tx", r.URL.Path)
+		n := int(requests.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(&broadcaster.ArcResponse{
+			Status: status,
+			Title:  http.StatusText(status),
+			Txid:   newTestTx().TxID().String(),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestArcBroadcast(t *testing.T) {
+	tx := newTestTx()
+	retry := broadcaster.RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}
+
+	t.Run("returns the txid", func(t *testing.T) {
+		server, requests := arcServer(t, http.StatusOK)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client(), Retry: retry}
+		var _ transaction.ContextBroadcaster = arc
+
+		success, failure := tx.BroadcastContext(context.Background(), arc)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		server, requests := arcServer(t, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client(), Retry: retry}
+
+		success, failure := tx.Broadcast(arc)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+		require.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("returns the last server error", func(t *testing.T) {
+		server, requests := arcServer(t, http.StatusServiceUnavailable)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client(), Retry: retry}
+
+		_, failure := tx.Broadcast(arc)
+		require.NotNil(t, failure)
+		require.Equal(t, "503", failure.Code)
+		require.Equal(t, "Service Unavailable", failure.Description)
+		require.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("does not retry rejections", func(t *testing.T) {
+		server, requests := arcServer(t, 465, http.StatusOK)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client(), Retry: retry}
+
+		_, failure := tx.Broadcast(arc)
+		require.NotNil(t, failure)
+		require.Equal(t, "465", failure.Code)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("does not retry without a retry policy", func(t *testing.T) {
+		server, requests := arcServer(t, http.StatusInternalServerError, http.StatusOK)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client()}
+
+		_, failure := tx.Broadcast(arc)
+		require.NotNil(t, failure)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("retries timeouts", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				time.Sleep(100 * time.Millisecond)
+			}
+			_ = json.NewEncoder(w).Encode(&broadcaster.ArcResponse{Status: http.StatusOK, Txid: tx.TxID().String()})
+		}))
+		defer server.Close()
+		client := server.Client()
+		client.Timeout = 50 * time.Millisecond
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: client, Retry: retry}
+
+		success, failure := tx.Broadcast(arc)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+		require.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("stops retrying when the context is done", func(t *testing.T) {
+		server, requests := arcServer(t, http.StatusServiceUnavailable)
+		arc := &broadcaster.Arc{
+			ApiUrl: server.URL,
+			Client: server.Client(),
+			Retry:  broadcaster.RetryPolicy{MaxRetries: 10, Backoff: time.Hour},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, failure := tx.BroadcastContext(ctx, arc)
+		require.NotNil(t, failure)
+		require.Equal(t, int32(1), requests.Load())
+	})
+}
+
+func TestArcBroadcastHeaders(t *testing.T) {
+	for _, test := range []struct {
+		status    broadcaster.ArcStatus
+		waitFor   string
+		legacyFor string
+	}{
+		{broadcaster.SEEN_ON_NETWORK, "SEEN_ON_NETWORK", "8"},
+		{broadcaster.MINED, "MINED", ""},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+			require.Equal(t, test.waitFor, r.Header.Get("X-WaitFor"))
+			require.Equal(t, test.legacyFor, r.Header.Get("X-WaitForStatus"))
+			_ = json.NewEncoder(w).Encode(&broadcaster.ArcResponse{Status: http.StatusOK})
+		}))
+		arc := &broadcaster.Arc{ApiUrl: server.URL, ApiKey: "key", WaitForStatus: test.status}
+		_, failure := newTestTx().Broadcast(arc)
+		require.Nil(t, failure)
+		server.Close()
+	}
+}
+
+func TestArcQueries(t *testing.T) {
+	tx := newTestTx()
+	txid := tx.TxID()
+	path, err := transaction.NewMerklePathFromBlockTxids(850000, []*chainhash.Hash{txid}, txid)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/tx/{txid}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("txid") != txid.String() {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"title":"Not found","detail":"The requested resource could not be found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"blockHash": "0000000000000000000e7d0c2a7a1c5b0e1e4f5c8a3c2f1e0d9c8b7a6f5e4d3c",
+			"blockHeight": 850000,
+			"competingTxs": ["` + strings.Repeat("ab", 32) + `"],
+			"extraInfo": "mined in a block",
+			"merklePath": "` + path.Hex() + `",
+			"timestamp": "2024-06-01T12:00:00Z",
+			"txStatus": "MINED",
+			"txid": "` + txid.String() + `"
+		}`))
+	})
+	mux.HandleFunc("GET /v1/policy", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"policy": {
+				"maxscriptsizepolicy": 100000000,
+				"maxtxsigopscountspolicy": 4294967295,
+				"maxtxsizepolicy": 100000000,
+				"miningFee": {"satoshis": 1, "bytes": 1000}
+			},
+			"timestamp": "2024-06-01T12:00:00Z"
+		}`))
+	})
+	mux.HandleFunc("POST /v1/txs", func(w http.ResponseWriter, r *http.Request) {
+		var rawTxs []struct {
+			RawTx string `json:"rawTx"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&rawTxs))
+		require.Len(t, rawTxs, 2)
+		ef, err := tx.EF()
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(ef), rawTxs[0].RawTx)
+		status := broadcaster.SEEN_ON_NETWORK
+		_ = json.NewEncoder(w).Encode([]*broadcaster.ArcResponse{
+			{Status: http.StatusOK, Title: "OK", Txid: txid.String(), TxStatus: &status},
+			{Status: 461, Title: "Malformed transaction", ExtraInfo: "script validation failed"},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	arc := &broadcaster.Arc{ApiUrl: server.URL + "/v1", Client: server.Client()}
+	ctx := context.Background()
+
+	t.Run("returns the status of a transaction", func(t *testing.T) {
+		status, err := arc.GetStatus(ctx, txid.String())
+		require.NoError(t, err)
+		require.Equal(t, broadcaster.MINED, *status.TxStatus)
+		require.Equal(t, uint32(850000), status.BlockHeight)
+		require.Equal(t, "mined in a block", status.ExtraInfo)
+		require.Equal(t, []string{strings.Repeat("ab", 32)}, status.CompetingTxs)
+
+		mp, err := status.ParseMerklePath()
+		require.NoError(t, err)
+		require.Equal(t, path.Hex(), mp.Hex())
+	})
+
+	t.Run("returns an error for unknown transactions", func(t *testing.T) {
+		_, err := arc.GetStatus(ctx, strings.Repeat("00", 32))
+		var arcErr *broadcaster.ArcError
+		require.ErrorAs(t, err, &arcErr)
+		require.Equal(t, http.StatusNotFound, arcErr.Status)
+		require.Equal(t, "Not found", arcErr.Title)
+	})
+
+	t.Run("returns the policy", func(t *testing.T) {
+		policy, err := arc.GetPolicy(ctx)
+		require.NoError(t, err)
+		require.Equal(t, broadcaster.ArcFee{Satoshis: 1, Bytes: 1000}, policy.Policy.MiningFee)
+		require.Equal(t, uint64(100000000), policy.Policy.MaxTxSizePolicy)
+	})
+
+	t.Run("broadcasts transactions in a batch", func(t *testing.T) {
+		responses, err := arc.BroadcastMany(ctx, []*transaction.Transaction{tx, newTestTx()})
+		require.NoError(t, err)
+		require.Len(t, responses, 2)
+		require.Equal(t, broadcaster.SEEN_ON_NETWORK, *responses[0].TxStatus)
+		require.Equal(t, 461, responses[1].Status)
+		require.Equal(t, "script validation failed", responses[1].ExtraInfo)
+
+		mp, err := responses[0].ParseMerklePath()
+		require.NoError(t, err)
+		require.Nil(t, mp)
+	})
+}
+
+func TestArcBroadcastFormat(t *testing.T) {
+	spend := func(sources ...*transaction.Transaction) *transaction.Transaction {
+		tx := newTestTx()
+		for _, source := range sources {
+			tx.AddInputFromTx(source, 0, nil)
+		}
+		for _, input := range tx.Inputs {
+			input.UnlockingScript = &script.Script{script.OpTRUE}
+		}
+		return tx
+	}
+	proven := newTestTx()
+	path, err := transaction.NewMerklePathFromBlockTxids(850000, []*chainhash.Hash{proven.TxID()}, proven.TxID())
+	require.NoError(t, err)
+	proven.MerklePath = path
+
+	unproven := spend(proven)
+	orphan := spend()
+	orphan.AddInputFrom(strings.Repeat("00", 32), 0, "51", 1, nil)
+	orphan.Inputs[0].UnlockingScript = &script.Script{script.OpTRUE}
+	unknownSource := spend()
+	unknownSource.AddInput(&transaction.TransactionInput{
+		SourceTXID:      proven.TxID(),
+		UnlockingScript: &script.Script{script.OpTRUE},
+	})
+
+	beef := func(tx *transaction.Transaction) []byte {
+		b, err := tx.BEEF()
+		require.NoError(t, err)
+		return b
+	}
+	ef := func(tx *transaction.Transaction) []byte {
+		b, err := tx.EF()
+		require.NoError(t, err)
+		return b
+	}
+
+	for _, test := range []struct {
+		name     string
+		tx       *transaction.Transaction
+		format   broadcaster.TxFormat
+		expected func(*transaction.Transaction) []byte
+	}{
+		{"sends BEEF for unproven parents", spend(unproven), broadcaster.FormatAuto, beef},
+		{"sends BEEF for a chain of unproven parents", spend(spend(unproven), proven), broadcaster.FormatAuto, beef},
+		{"sends EF for proven parents", spend(proven), broadcaster.FormatAuto, ef},
+		{"sends EF for known source outputs", orphan, broadcaster.FormatAuto, ef},
+		{"sends EF for an incomplete ancestry", spend(spend(orphan)), broadcaster.FormatAuto, ef},
+		{"sends raw transactions for unknown source outputs", unknownSource, broadcaster.FormatAuto, (*transaction.Transaction).Bytes},
+		{"forces raw transactions", spend(unproven), broadcaster.FormatRaw, (*transaction.Transaction).Bytes},
+		{"forces EF", spend(unproven), broadcaster.FormatEF, ef},
+		{"forces BEEF", spend(proven), broadcaster.FormatBEEF, beef},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, test.expected(test.tx), body)
+				_ = json.NewEncoder(w).Encode(&broadcaster.ArcResponse{Status: http.StatusOK, Txid: test.tx.TxID().String()})
+			}))
+			defer server.Close()
+
+			arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client(), Format: test.format}
+			_, failure := test.tx.Broadcast(arc)
+			require.Nil(t, failure)
+		})
+	}
+
+	t.Run("fails to force an impossible format", func(t *testing.T) {
+		arc := &broadcaster.Arc{ApiUrl: "http://localhost", Format: broadcaster.FormatEF}
+		_, failure := unknownSource.Broadcast(arc)
+		require.NotNil(t, failure)
+	})
+}
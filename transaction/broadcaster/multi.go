@@ -0,0 +1,193 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+// Strategy is the way a Multi broadcaster uses its broadcasters.
+type Strategy int
+
+const (
+	// Failover tries the broadcasters one after the other in priority
+	// order, until one of them accepts the transaction.
+	Failover Strategy = iota
+	// FanOut broadcasts to every broadcaster at once, succeeding as soon as
+	// one of them accepts the transaction.
+	FanOut
+	// Quorum broadcasts to every broadcaster at once, succeeding once
+	// Quorum of them accepted the transaction.
+	Quorum
+)
+
+// alreadyKnownMessages are parts of the rejections of a transaction which
+// was already broadcast, and thus is as good as accepted.
+var alreadyKnownMessages = []string{
+	"already known",
+	"txn-already-known",
+	"already in the mempool",
+	"txn-already-in-mempool",
+}
+
+// Multi broadcasts through several broadcasters for redundancy, according
+// to its Strategy. A rejection of the transaction as already known counts
+// as an acceptance. When the transaction is not accepted, the failures of
+// every broadcaster are reported in a single BroadcastFailure.
+type Multi struct {
+	Broadcasters []transaction.Broadcaster
+	Strategy     Strategy
+	// Quorum is the number of broadcasters which must accept the
+	// transaction with the Quorum strategy, a majority of them when zero.
+	Quorum int
+}
+
+// NewMulti returns a Multi broadcaster using the broadcasters, given in
+// priority order, according to strategy.
+func NewMulti(strategy Strategy, broadcasters ...transaction.Broadcaster) *Multi {
+	return &Multi{
+		Broadcasters: broadcasters,
+		Strategy:     strategy,
+	}
+}
+
+func (m *Multi) Broadcast(t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	return m.BroadcastContext(context.Background(), t)
+}
+
+func (m *Multi) BroadcastContext(ctx context.Context, t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	if len(m.Broadcasters) == 0 {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: "no broadcaster configured",
+		}
+	}
+	switch m.Strategy {
+	case Failover:
+		return m.failover(ctx, t)
+	case FanOut:
+		return m.parallel(ctx, t, 1)
+	case Quorum:
+		quorum := m.Quorum
+		if quorum <= 0 {
+			quorum = len(m.Broadcasters)/2 + 1
+		}
+		if quorum > len(m.Broadcasters) {
+			return nil, &transaction.BroadcastFailure{
+				Code:        "500",
+				Description: fmt.Sprintf("quorum of %d out of %d broadcasters", quorum, len(m.Broadcasters)),
+			}
+		}
+		return m.parallel(ctx, t, quorum)
+	default:
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: fmt.Sprintf("unknown broadcast strategy %d", m.Strategy),
+		}
+	}
+}
+
+func (m *Multi) failover(ctx context.Context, t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	failures := make([]*transaction.BroadcastFailure, len(m.Broadcasters))
+	for i, b := range m.Broadcasters {
+		success, failure := broadcastOne(ctx, t, b)
+		if failure == nil {
+			return success, nil
+		}
+		failures[i] = failure
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, aggregateFailures(failures)
+}
+
+type multiResult struct {
+	index   int
+	success *transaction.BroadcastSuccess
+	failure *transaction.BroadcastFailure
+}
+
+// parallel broadcasts with every broadcaster at once, returning once need
+// of them accepted the transaction or too many of them failed to.
+func (m *Multi) parallel(ctx context.Context, t *transaction.Transaction, need int) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan multiResult, len(m.Broadcasters))
+	for i, b := range m.Broadcasters {
+		go func(i int, b transaction.Broadcaster) {
+			success, failure := broadcastOne(ctx, t, b)
+			results <- multiResult{index: i, success: success, failure: failure}
+		}(i, b)
+	}
+
+	failures := make([]*transaction.BroadcastFailure, len(m.Broadcasters))
+	var first *transaction.BroadcastSuccess
+	accepted, failed := 0, 0
+	for range m.Broadcasters {
+		result := <-results
+		if result.failure == nil {
+			if first == nil {
+				first = result.success
+			}
+			if accepted++; accepted >= need {
+				return first, nil
+			}
+			continue
+		}
+		failures[result.index] = result.failure
+		if failed++; len(m.Broadcasters)-failed < need {
+			break
+		}
+	}
+	return nil, aggregateFailures(failures)
+}
+
+// broadcastOne broadcasts with b, turning rejections of the transaction as
+// already known into a success.
+func broadcastOne(ctx context.Context, t *transaction.Transaction, b transaction.Broadcaster) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	success, failure := t.BroadcastContext(ctx, b)
+	if failure != nil && isAlreadyKnown(failure) {
+		return &transaction.BroadcastSuccess{
+			Txid:    t.TxID().String(),
+			Message: failure.Description,
+		}, nil
+	}
+	return success, failure
+}
+
+func isAlreadyKnown(failure *transaction.BroadcastFailure) bool {
+	description := strings.ToLower(failure.Description)
+	for _, msg := range alreadyKnownMessages {
+		if strings.Contains(description, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregateFailures reports the failures of the broadcasters, in priority
+// order, as a single failure. Its code is the one shared by the failures,
+// or 500 when they differ.
+func aggregateFailures(failures []*transaction.BroadcastFailure) *transaction.BroadcastFailure {
+	var code string
+	var descriptions []string
+	for i, failure := range failures {
+		if failure == nil {
+			continue
+		}
+		if code == "" {
+			code = failure.Code
+		} else if code != failure.Code {
+			code = "500"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("broadcaster %d: %s %s", i, failure.Code, failure.Description))
+	}
+	return &transaction.BroadcastFailure{
+		Code:        code,
+		Description: strings.Join(descriptions, "; "),
+	}
+}
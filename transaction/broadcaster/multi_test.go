@@ -0,0 +1,160 @@
+package broadcaster_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroadcaster answers after delay with failure, or a success when nil,
+// unless its context is done first.
+type fakeBroadcaster struct {
+	delay   time.Duration
+	failure *transaction.BroadcastFailure
+	calls   atomic.Int32
+}
+
+func (f *fakeBroadcaster) Broadcast(t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	return f.BroadcastContext(context.Background(), t)
+}
+
+func (f *fakeBroadcaster) BroadcastContext(ctx context.Context, t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	f.calls.Add(1)
+	select {
+	case <-ctx.Done():
+		return nil, &transaction.BroadcastFailure{Code: "500", Description: ctx.Err().Error()}
+	case <-time.After(f.delay):
+	}
+	if f.failure != nil {
+		return nil, f.failure
+	}
+	return &transaction.BroadcastSuccess{Txid: t.TxID().String(), Message: "accepted"}, nil
+}
+
+func accepting(delay time.Duration) *fakeBroadcaster {
+	return &fakeBroadcaster{delay: delay}
+}
+
+func rejecting(code string, description string) *fakeBroadcaster {
+	return &fakeBroadcaster{failure: &transaction.BroadcastFailure{Code: code, Description: description}}
+}
+
+func TestMultiBroadcast(t *testing.T) {
+	tx := newTestTx()
+
+	t.Run("fails over in priority order", func(t *testing.T) {
+		first, second, third := rejecting("503", "unavailable"), accepting(0), accepting(0)
+		multi := broadcaster.NewMulti(broadcaster.Failover, first, second, third)
+		var _ transaction.ContextBroadcaster = multi
+
+		success, failure := tx.Broadcast(multi)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+		require.Equal(t, int32(1), first.calls.Load())
+		require.Equal(t, int32(1), second.calls.Load())
+		require.Equal(t, int32(0), third.calls.Load())
+	})
+
+	t.Run("aggregates failures", func(t *testing.T) {
+		multi := broadcaster.NewMulti(broadcaster.Failover, rejecting("465", "fee too low"), rejecting("465", "fee is too low"))
+		_, failure := tx.Broadcast(multi)
+		require.NotNil(t, failure)
+		require.Equal(t, "465", failure.Code)
+		require.Equal(t, "broadcaster 0: 465 fee too low; broadcaster 1: 465 fee is too low", failure.Description)
+
+		multi = broadcaster.NewMulti(broadcaster.FanOut, rejecting("465", "fee too low"), rejecting("503", "unavailable"))
+		_, failure = tx.Broadcast(multi)
+		require.NotNil(t, failure)
+		require.Equal(t, "500", failure.Code)
+		require.Contains(t, failure.Description, "broadcaster 1: 503 unavailable")
+	})
+
+	t.Run("treats already known transactions as accepted", func(t *testing.T) {
+		for _, description := range []string{"Already known", "257: txn-already-known", "Transaction already in the mempool"} {
+			multi := broadcaster.NewMulti(broadcaster.Failover, rejecting("400", description))
+			success, failure := tx.Broadcast(multi)
+			require.Nil(t, failure)
+			require.Equal(t, tx.TxID().String(), success.Txid)
+		}
+	})
+
+	t.Run("treats transactions already known to arc as accepted", func(t *testing.T) {
+		// ARC tells why a transaction is rejected in the detail and extra
+		// info of its error, not in the title.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(460)
+			_, _ = w.Write([]byte(`{
+				"type": "https://bitcoin-sv.github.io/arc/#/errors?id=_460",
+				"title": "Not extended format",
+				"status": 460,
+				"detail": "Transaction could not be processed",
+				"extraInfo": "arc error 460: 257: txn-already-known",
+				"txid": "` + tx.TxID().String() + `"
+			}`))
+		}))
+		t.Cleanup(server.Close)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client()}
+
+		_, failure := tx.Broadcast(arc)
+		require.Equal(t, "460", failure.Code)
+		require.Equal(t, "Not extended format: Transaction could not be processed: arc error 460: 257: txn-already-known", failure.Description)
+
+		multi := broadcaster.NewMulti(broadcaster.Failover, arc, rejecting("503", "unavailable"))
+		success, failure := tx.Broadcast(multi)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+	})
+
+	t.Run("fans out returning on the first acceptance", func(t *testing.T) {
+		slow := accepting(time.Hour)
+		multi := broadcaster.NewMulti(broadcaster.FanOut, rejecting("503", "unavailable"), slow, accepting(time.Millisecond))
+
+		start := time.Now()
+		success, failure := tx.Broadcast(multi)
+		require.Nil(t, failure)
+		require.Equal(t, "accepted", success.Message)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, int32(1), slow.calls.Load())
+	})
+
+	t.Run("waits for a quorum", func(t *testing.T) {
+		multi := broadcaster.NewMulti(broadcaster.Quorum, accepting(0), accepting(10*time.Millisecond), rejecting("503", "unavailable"))
+		_, failure := tx.Broadcast(multi)
+		require.Nil(t, failure)
+
+		multi = broadcaster.NewMulti(broadcaster.Quorum, accepting(0), rejecting("503", "unavailable"), rejecting("465", "fee too low"))
+		_, failure = tx.Broadcast(multi)
+		require.NotNil(t, failure)
+		require.Contains(t, failure.Description, "broadcaster 1: 503 unavailable")
+		require.Contains(t, failure.Description, "broadcaster 2: 465 fee too low")
+
+		multi.Quorum = 1
+		_, failure = tx.Broadcast(multi)
+		require.Nil(t, failure)
+	})
+
+	t.Run("gives up on an impossible quorum early", func(t *testing.T) {
+		multi := broadcaster.NewMulti(broadcaster.Quorum, rejecting("503", "unavailable"), rejecting("503", "unavailable"), accepting(time.Hour))
+		start := time.Now()
+		_, failure := tx.Broadcast(multi)
+		require.NotNil(t, failure)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("combines any broadcaster", func(t *testing.T) {
+		server, _ := arcServer(t, 200)
+		arc := &broadcaster.Arc{ApiUrl: server.URL, Client: server.Client()}
+		multi := broadcaster.NewMulti(broadcaster.Failover, rejecting("503", "unavailable"), arc)
+		success, failure := tx.Broadcast(multi)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+	})
+}
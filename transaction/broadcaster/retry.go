@@ -0,0 +1,92 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Default RetryPolicy backoffs.
+const (
+	DefaultRetryBackoff    = 500 * time.Millisecond
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// RetryPolicy configures retrying requests which failed because of a
+// network error, a timeout or a server error. Rejections of the request are
+// never retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled for each of the
+	// next ones. DefaultRetryBackoff when zero.
+	Backoff time.Duration
+	// MaxBackoff bounds the delay between retries. DefaultRetryMaxBackoff
+	// when zero.
+	MaxBackoff time.Duration
+}
+
+// do calls attempt until it succeeds, fails with an error which is not
+// worth retrying, runs out of retries or ctx is done. It returns the last
+// error.
+func (p RetryPolicy) do(ctx context.Context, attempt func() error) error {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	for retry := 0; ; retry++ {
+		err := attempt()
+		if err == nil || retry >= p.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				return perm.err
+			}
+			return err
+		}
+
+		timer := time.NewTimer(min(backoff, maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// permanentError marks an error which is not worth retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func permanent(err error) error {
+	return &permanentError{err}
+}
+
+func retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	var temp interface{ temporary() bool }
+	if errors.As(err, &temp) {
+		return temp.temporary()
+	}
+	// Any other error comes from the transport, such as a timeout or a
+	// connection reset.
+	return true
+}
@@ -0,0 +1,139 @@
+package broadcaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/whatsonchain"
+)
+
+// WhatsOnChain broadcasts raw transactions through the WhatsOnChain API.
+type WhatsOnChain struct {
+	Network whatsonchain.Network
+	// ApiKey is sent in the Authorization header when not empty.
+	ApiKey string
+	// BaseUrl replaces whatsonchain.Url when not empty.
+	BaseUrl string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// NewWhatsOnChain returns a WhatsOnChain broadcaster for the network.
+func NewWhatsOnChain(network whatsonchain.Network, apiKey string) *WhatsOnChain {
+	return &WhatsOnChain{
+		Network: network,
+		ApiKey:  apiKey,
+	}
+}
+
+type wocBroadcastRequest struct {
+	TxHex string `json:"txhex"`
+}
+
+func (w *WhatsOnChain) Broadcast(t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	return w.BroadcastContext(context.Background(), t)
+}
+
+func (w *WhatsOnChain) BroadcastContext(ctx context.Context, t *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	body, err := json.Marshal(&wocBroadcastRequest{TxHex: t.Hex()})
+	if err != nil {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
+		}
+	}
+
+	baseUrl := w.BaseUrl
+	if baseUrl == "" {
+		baseUrl = whatsonchain.Url
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/%s/tx/raw", baseUrl, w.Network),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if w.ApiKey != "" {
+		req.Header.Set("Authorization", w.ApiKey)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
+		}
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: err.Error(),
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &transaction.BroadcastFailure{
+			Code:        fmt.Sprintf("%d", resp.StatusCode),
+			Description: wocErrorMessage(msg),
+		}
+	}
+
+	// A successful broadcast answers with the txid as a JSON string.
+	var txid string
+	if err := json.Unmarshal(msg, &txid); err != nil {
+		txid = strings.TrimSpace(string(msg))
+	}
+	if txid != t.TxID().String() {
+		return nil, &transaction.BroadcastFailure{
+			Code:        "500",
+			Description: fmt.Sprintf("unexpected broadcast response: %s", txid),
+		}
+	}
+	return &transaction.BroadcastSuccess{
+		Txid:    txid,
+		Message: "broadcast successful",
+	}, nil
+}
+
+// wocErrorMessage extracts the error message from a WhatsOnChain error
+// body, which is either plain text, a JSON string or a JSON object.
+func wocErrorMessage(body []byte) string {
+	var message string
+	if err := json.Unmarshal(body, &message); err == nil {
+		return message
+	}
+	var object struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &object); err == nil {
+		if object.Error != "" {
+			return object.Error
+		}
+		if object.Message != "" {
+			return object.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,64 @@
+package broadcaster_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"github.com/bitcoin-sv/go-sdk/transaction/whatsonchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhatsOnChainBroadcast(t *testing.T) {
+	tx := newTestTx()
+
+	serve := func(t *testing.T, status int, body string) *broadcaster.WhatsOnChain {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "POST", r.Method)
+			require.Equal(t, "/main/tx/raw", r.URL.Path)
+			require.Equal(t, "key", r.Header.Get("Authorization"))
+			var req struct {
+				TxHex string `json:"txhex"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, tx.Hex(), req.TxHex)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		woc := broadcaster.NewWhatsOnChain(whatsonchain.MainNet, "key")
+		woc.BaseUrl = server.URL
+		woc.Client = server.Client()
+		return woc
+	}
+
+	t.Run("returns the txid", func(t *testing.T) {
+		woc := serve(t, http.StatusOK, `"`+tx.TxID().String()+`"`)
+		success, failure := tx.Broadcast(woc)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+	})
+
+	t.Run("rejects an unexpected txid", func(t *testing.T) {
+		woc := serve(t, http.StatusOK, `"00"`)
+		_, failure := tx.Broadcast(woc)
+		require.NotNil(t, failure)
+		require.Equal(t, "500", failure.Code)
+	})
+
+	t.Run("returns the error message", func(t *testing.T) {
+		for _, body := range []string{
+			"unexpected response code 500: 258: txn-mempool-conflict",
+			`"unexpected response code 500: 258: txn-mempool-conflict"`,
+			`{"error":"unexpected response code 500: 258: txn-mempool-conflict"}`,
+		} {
+			woc := serve(t, http.StatusBadRequest, body)
+			_, failure := tx.Broadcast(woc)
+			require.NotNil(t, failure)
+			require.Equal(t, "400", failure.Code)
+			require.Equal(t, "unexpected response code 500: 258: txn-mempool-conflict", failure.Description)
+		}
+	})
+}
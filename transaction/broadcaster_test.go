@@ -0,0 +1,39 @@
+package transaction_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+type plainBroadcaster struct {
+	calls int
+}
+
+func (b *plainBroadcaster) Broadcast(tx *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	b.calls++
+	return &transaction.BroadcastSuccess{Txid: tx.TxID().String()}, nil
+}
+
+func TestBroadcastContext(t *testing.T) {
+	tx := transaction.NewTransaction()
+
+	t.Run("falls back to Broadcast", func(t *testing.T) {
+		b := &plainBroadcaster{}
+		success, failure := tx.BroadcastContext(context.Background(), b)
+		require.Nil(t, failure)
+		require.Equal(t, tx.TxID().String(), success.Txid)
+		require.Equal(t, 1, b.calls)
+	})
+
+	t.Run("does not broadcast once the context is done", func(t *testing.T) {
+		b := &plainBroadcaster{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, failure := tx.BroadcastContext(ctx, b)
+		require.NotNil(t, failure)
+		require.Equal(t, 0, b.calls)
+	})
+}
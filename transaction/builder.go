@@ -0,0 +1,284 @@
+package transaction
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/pkg/errors"
+)
+
+// DefaultBuilderIterations bounds the rounds of a Builder when its
+// MaxIterations is zero.
+const DefaultBuilderIterations = 10
+
+// InputSelector picks, among a pool of UTXOs, the ones funding the outputs
+// of a transaction and the fee computed by the fee model. The
+// transaction passed is a draft which must be left untouched.
+type InputSelector interface {
+	SelectInputs(pool []*UTXO, tx *Transaction, f FeeModel) ([]*UTXO, error)
+}
+
+// ShortfallError is returned by Builder when its UTXOs can not fund the
+// transaction. It matches ErrInsufficientFunds.
+type ShortfallError struct {
+	// Shortfall is the amount missing for all the UTXOs to fund the
+	// payees and the fee, without change. Funding it with more UTXOs costs
+	// the fee of their inputs on top.
+	Shortfall uint64
+}
+
+func (e *ShortfallError) Error() string {
+	return fmt.Sprintf("%s: %d satoshis short", ErrInsufficientFunds, e.Shortfall)
+}
+
+func (e *ShortfallError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// Builder builds signed transactions paying its payees from its UTXOs,
+// sending what is left to a change output.
+//
+// Fee computes the fee with the estimated length of the unlocking scripts,
+// which signing may exceed. Builder selects inputs, computes the fee, signs
+// and measures the fee of the signed transaction, starting over with the
+// difference added to the fee until the transaction pays the fee of its
+// actual size.
+type Builder struct {
+	Payees []*TransactionOutput
+	UTXOs  []*UTXO
+	// ChangeScript locks the change output. Without it, what is left goes
+	// to the miners.
+	ChangeScript *script.Script
+	FeeModel     FeeModel
+	// Selector selects the UTXOs to spend, the largest first when nil.
+	Selector InputSelector
+	// MaxIterations bounds the rounds of signing, DefaultBuilderIterations
+	// when zero.
+	MaxIterations int
+}
+
+// NewBuilder returns a Builder computing fees with feeModel and sending the
+// change to changeScript.
+func NewBuilder(feeModel FeeModel, changeScript *script.Script) *Builder {
+	return &Builder{
+		FeeModel:     feeModel,
+		ChangeScript: changeScript,
+	}
+}
+
+// PayTo adds a payee paid satoshis to lockingScript.
+func (b *Builder) PayTo(lockingScript *script.Script, satoshis uint64) *Builder {
+	b.Payees = append(b.Payees, &TransactionOutput{
+		Satoshis:      satoshis,
+		LockingScript: lockingScript,
+	})
+	return b
+}
+
+// AddUTXOs adds UTXOs the transaction may spend. They need an
+// UnlockingScriptTemplate to be signed.
+func (b *Builder) AddUTXOs(utxos ...*UTXO) *Builder {
+	b.UTXOs = append(b.UTXOs, utxos...)
+	return b
+}
+
+// Build returns the signed transaction. It fails with a ShortfallError when
+// the UTXOs can not fund it, and with ErrFeeNotConverged when signing keeps
+// changing its size past MaxIterations.
+func (b *Builder) Build() (*Transaction, error) {
+	if b.FeeModel == nil {
+		return nil, ErrNoFeeModel
+	}
+	selector := b.Selector
+	if selector == nil {
+		selector = largestFirst{}
+	}
+	maxIterations := b.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultBuilderIterations
+	}
+
+	fees := &paddedFeeModel{FeeModel: b.FeeModel}
+	for i := 0; i < maxIterations; i++ {
+		draft := b.draft()
+		utxos, err := selector.SelectInputs(b.UTXOs, draft, fees)
+		if err != nil {
+			return nil, b.shortfall(draft, fees, err)
+		}
+		if err := draft.AddInputsFromUTXOs(utxos...); err != nil {
+			return nil, err
+		}
+		// Fee needs the change outputs removed beforehand when the inputs
+		// do not cover their own fee.
+		if err := dropUnfundedChange(draft, fees); err != nil {
+			return nil, err
+		}
+		if err := draft.Fee(fees, ChangeDistributionEqual); err != nil {
+			if errors.Is(err, ErrInsufficientInputs) {
+				return nil, b.shortfall(b.draft(), fees, err)
+			}
+			return nil, err
+		}
+		estimate, err := b.FeeModel.ComputeFee(draft)
+		if err != nil {
+			return nil, err
+		}
+		if err := draft.Sign(); err != nil {
+			return nil, err
+		}
+
+		fee, err := b.FeeModel.ComputeFee(draft)
+		if err != nil {
+			return nil, err
+		}
+		if draft.TotalInputSatoshis()-draft.TotalOutputSatoshis() >= fee {
+			return draft, nil
+		}
+		// The draft paid at least the estimate and the padding, the padding
+		// falls short of what signing added.
+		fees.padding = fee - estimate
+	}
+	return nil, ErrFeeNotConverged
+}
+
+// draft returns the unfunded transaction paying the payees.
+func (b *Builder) draft() *Transaction {
+	tx := NewTransaction()
+	for _, payee := range b.Payees {
+		tx.AddOutput(&TransactionOutput{
+			Satoshis:      payee.Satoshis,
+			LockingScript: payee.LockingScript,
+		})
+	}
+	if b.ChangeScript != nil {
+		tx.AddOutput(&TransactionOutput{
+			LockingScript: b.ChangeScript,
+			Change:        true,
+		})
+	}
+	return tx
+}
+
+func dropUnfundedChange(tx *Transaction, f FeeModel) error {
+	var changeOutputs uint64
+	for _, output := range tx.Outputs {
+		if output.Change {
+			changeOutputs++
+		}
+	}
+	if changeOutputs == 0 {
+		return nil
+	}
+	fee, err := f.ComputeFee(tx)
+	if err != nil {
+		return err
+	}
+	if tx.TotalInputSatoshis() < tx.TotalOutputSatoshis()+fee+changeOutputs {
+		tx.Outputs = slices.DeleteFunc(tx.Outputs, func(o *TransactionOutput) bool {
+			return o.Change
+		})
+	}
+	return nil
+}
+
+// shortfall returns the ShortfallError of spending all the UTXOs, or err
+// when they are enough and the selection failed for another reason.
+func (b *Builder) shortfall(draft *Transaction, fees FeeModel, err error) error {
+	fee, feeErr := withoutChange(draft).FeeSpending(fees, b.UTXOs...)
+	if feeErr != nil {
+		return feeErr
+	}
+	var available uint64
+	for _, utxo := range b.UTXOs {
+		available += utxo.Satoshis
+	}
+	if need := draft.TotalOutputSatoshis() + fee; available < need {
+		return &ShortfallError{Shortfall: need - available}
+	}
+	return err
+}
+
+// paddedFeeModel adds padding to the fees computed by a FeeModel, making
+// up for the estimates of the unlocking scripts.
+type paddedFeeModel struct {
+	FeeModel
+	padding uint64
+}
+
+func (p *paddedFeeModel) ComputeFee(tx *Transaction) (uint64, error) {
+	fee, err := p.FeeModel.ComputeFee(tx)
+	if err != nil {
+		return 0, err
+	}
+	return fee + p.padding, nil
+}
+
+// largestFirst selects the largest UTXOs until they fund the transaction
+// with change, or, when the pool runs out, without. It is the default of
+// Builder, coinselection.LargestFirst importing this package. As there, the
+// fee of a UTXO is estimated from what it adds to the fee of the transaction
+// alone, the fee model confirming the selection the estimates tell funded.
+type largestFirst struct{}
+
+func (largestFirst) SelectInputs(pool []*UTXO, tx *Transaction, f FeeModel) ([]*UTXO, error) {
+	sorted := slices.Clone(pool)
+	slices.SortStableFunc(sorted, func(a, b *UTXO) int {
+		return cmp.Compare(b.Satoshis, a.Satoshis)
+	})
+
+	bare := withoutChange(tx)
+	changeOutputs := uint64(len(tx.Outputs) - len(bare.Outputs))
+	target := tx.TotalOutputSatoshis()
+	baseFee, err := tx.FeeSpending(f)
+	if err != nil {
+		return nil, err
+	}
+	// cost is the estimated fee of the UTXOs added.
+	var total, cost uint64
+	for n, utxo := range sorted {
+		fee, err := tx.FeeSpending(f, utxo)
+		if err != nil {
+			return nil, err
+		}
+		cost += fee - baseFee
+		total += utxo.Satoshis
+		if total < target+baseFee+cost+changeOutputs {
+			continue
+		}
+		fee, err = tx.FeeSpending(f, sorted[:n+1]...)
+		if err != nil {
+			return nil, err
+		}
+		if total >= target+fee+changeOutputs {
+			return sorted[:n+1], nil
+		}
+		// The estimate fell short, carry on from the actual fee.
+		cost = fee - baseFee
+	}
+	fee, err := bare.FeeSpending(f, sorted...)
+	if err != nil {
+		return nil, err
+	}
+	if total < target+fee {
+		return nil, ErrInsufficientFunds
+	}
+	return sorted, nil
+}
+
+// withoutChange returns a copy of the transaction without its change
+// outputs, sharing its inputs and other outputs.
+func withoutChange(tx *Transaction) *Transaction {
+	bare := &Transaction{
+		Version:  tx.Version,
+		LockTime: tx.LockTime,
+		Inputs:   tx.Inputs,
+	}
+	for _, output := range tx.Outputs {
+		if !output.Change {
+			bare.Outputs = append(bare.Outputs, output)
+		}
+	}
+	return bare
+}
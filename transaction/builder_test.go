@@ -0,0 +1,176 @@
+package transaction_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	feemodel "github.com/bitcoin-sv/go-sdk/transaction/fee_model"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+// sizedUnlocker signs with a script of length size, while estimating it
+// at estimate.
+type sizedUnlocker struct {
+	estimate, size uint32
+}
+
+func (u sizedUnlocker) Sign(*transaction.Transaction, uint32) (*script.Script, error) {
+	s := make(script.Script, u.size)
+	return &s, nil
+}
+
+func (u sizedUnlocker) EstimateLength(*transaction.Transaction, uint32) uint32 {
+	return u.estimate
+}
+
+// perByteFee charges a satoshi per byte of unlocking script, and 10
+// satoshis per input and output.
+type perByteFee struct{}
+
+func (perByteFee) ComputeFee(tx *transaction.Transaction) (uint64, error) {
+	fee := uint64(10 * (len(tx.Inputs) + len(tx.Outputs)))
+	for vin, input := range tx.Inputs {
+		if input.UnlockingScript != nil {
+			fee += uint64(len(*input.UnlockingScript))
+		} else {
+			fee += uint64(input.UnlockingScriptTemplate.EstimateLength(tx, uint32(vin)))
+		}
+	}
+	return fee, nil
+}
+
+func builderUTXO(index byte, satoshis uint64, unlocker transaction.UnlockingScriptTemplate) *transaction.UTXO {
+	return &transaction.UTXO{
+		TxID:                    &chainhash.Hash{index},
+		Vout:                    uint32(index),
+		LockingScript:           &script.Script{script.OpTRUE},
+		Satoshis:                satoshis,
+		UnlockingScriptTemplate: unlocker,
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	lock := &script.Script{script.OpTRUE}
+	change := &script.Script{script.OpTRUE, script.OpTRUE}
+
+	t.Run("pays the fee of the signed size", func(t *testing.T) {
+		// Signatures come out 20 bytes longer than estimated.
+		unlocker := sizedUnlocker{estimate: 10, size: 30}
+		tx, err := transaction.NewBuilder(perByteFee{}, change).
+			PayTo(lock, 1000).
+			AddUTXOs(builderUTXO(1, 800, unlocker), builderUTXO(2, 700, unlocker)).
+			Build()
+		require.NoError(t, err)
+
+		require.Len(t, tx.Inputs, 2)
+		for _, input := range tx.Inputs {
+			require.Len(t, *input.UnlockingScript, 30)
+		}
+		require.Len(t, tx.Outputs, 2)
+		require.True(t, tx.Outputs[1].Change)
+		// 2 inputs of 30 bytes and 2 outputs.
+		require.Equal(t, uint64(1500-1000-100), tx.Outputs[1].Satoshis)
+	})
+
+	t.Run("drops the change when too little is left", func(t *testing.T) {
+		unlocker := sizedUnlocker{estimate: 10, size: 30}
+		tx, err := transaction.NewBuilder(perByteFee{}, change).
+			PayTo(lock, 1000).
+			AddUTXOs(builderUTXO(1, 1055, unlocker)).
+			Build()
+		require.NoError(t, err)
+		require.Len(t, tx.Outputs, 1)
+		require.Equal(t, uint64(55), tx.TotalInputSatoshis()-tx.TotalOutputSatoshis())
+	})
+
+	t.Run("reports the shortfall", func(t *testing.T) {
+		unlocker := sizedUnlocker{estimate: 10, size: 10}
+		_, err := transaction.NewBuilder(perByteFee{}, change).
+			PayTo(lock, 1000).
+			AddUTXOs(builderUTXO(1, 500, unlocker), builderUTXO(2, 400, unlocker)).
+			Build()
+		require.ErrorIs(t, err, transaction.ErrInsufficientFunds)
+		var shortfall *transaction.ShortfallError
+		require.True(t, errors.As(err, &shortfall))
+		// 2 inputs of 10 bytes and a payee cost 50.
+		require.Equal(t, uint64(1050-900), shortfall.Shortfall)
+	})
+
+	t.Run("reports the shortfall found after signing", func(t *testing.T) {
+		// The estimate fits, the signed size does not.
+		unlocker := sizedUnlocker{estimate: 10, size: 40}
+		_, err := transaction.NewBuilder(perByteFee{}, change).
+			PayTo(lock, 1000).
+			AddUTXOs(builderUTXO(1, 1035, unlocker)).
+			Build()
+		var shortfall *transaction.ShortfallError
+		require.True(t, errors.As(err, &shortfall))
+		// An input of 40 bytes and a payee cost 60.
+		require.Equal(t, uint64(1060-1035), shortfall.Shortfall)
+	})
+
+	t.Run("gives up when the fee does not converge", func(t *testing.T) {
+		builder := transaction.NewBuilder(perByteFee{}, change).
+			PayTo(lock, 1000).
+			AddUTXOs(builderUTXO(1, 2000, sizedUnlocker{estimate: 10, size: 30}))
+		builder.MaxIterations = 1
+		_, err := builder.Build()
+		require.ErrorIs(t, err, transaction.ErrFeeNotConverged)
+	})
+
+	t.Run("requires a fee model", func(t *testing.T) {
+		_, err := transaction.NewBuilder(nil, change).PayTo(lock, 1000).Build()
+		require.ErrorIs(t, err, transaction.ErrNoFeeModel)
+	})
+
+	t.Run("signs p2pkh inputs", func(t *testing.T) {
+		priv, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		address, err := script.NewAddressFromPublicKey(priv.PubKey(), true)
+		require.NoError(t, err)
+		p2pkhLock, err := p2pkh.Lock(address)
+		require.NoError(t, err)
+		unlocker, err := p2pkh.Unlock(priv, nil)
+		require.NoError(t, err)
+
+		utxo := builderUTXO(1, 5000, unlocker)
+		utxo.LockingScript = p2pkhLock
+		tx, err := transaction.NewBuilder(&feemodel.SatoshisPerKilobyte{Satoshis: 50}, p2pkhLock).
+			PayTo(lock, 1000).
+			AddUTXOs(utxo).
+			Build()
+		require.NoError(t, err)
+		require.Equal(t, uint64(5000-1000-50), tx.Outputs[1].Satoshis)
+
+		err = interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, 0, tx.Inputs[0].SourceTxOutput()),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		)
+		require.NoError(t, err)
+	})
+}
+
+func TestFeeSpending(t *testing.T) {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 600, LockingScript: &script.Script{script.OpTRUE}})
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: &script.Script{script.OpTRUE}, Change: true})
+	utxos := []*transaction.UTXO{builderUTXO(1, 500, sizedUnlocker{estimate: 107}), builderUTXO(2, 300, sizedUnlocker{estimate: 107})}
+
+	fee, err := tx.FeeSpending(perByteFee{}, utxos...)
+	require.NoError(t, err)
+	require.Equal(t, uint64(40+2*107), fee)
+	fee, err = tx.FeeSpending(perByteFee{}, utxos[0])
+	require.NoError(t, err)
+	require.Equal(t, uint64(30+107), fee)
+
+	// The transaction is left untouched.
+	require.Empty(t, tx.Inputs)
+	require.Len(t, tx.Outputs, 2)
+}
@@ -0,0 +1,244 @@
+package chaintracker
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"golang.org/x/sync/singleflight"
+)
+
+// Default CacheOptions.
+const (
+	DefaultCacheSize     = 1024
+	DefaultCacheTipDepth = 6
+	DefaultCacheTipTTL   = 10 * time.Minute
+)
+
+// CacheOptions configures a Cache. Zero values take the defaults.
+type CacheOptions struct {
+	// Size bounds the number of merkle roots kept, the least recently used
+	// being evicted first.
+	Size int
+	// TipDepth is the number of blocks from the tip of the chain within
+	// which a block may still be reorganized away.
+	TipDepth uint32
+	// TipTTL is how long merkle roots of blocks within TipDepth of the tip,
+	// and the height of the tip itself, are kept.
+	TipTTL time.Duration
+	// OnHit, when set, is called for each merkle root answered from the
+	// cache.
+	OnHit func(root *chainhash.Hash, height uint32)
+	// OnMiss, when set, is called for each merkle root looked up from the
+	// wrapped chain tracker, including lookups sharing a pending request.
+	OnMiss func(root *chainhash.Hash, height uint32)
+}
+
+// CacheStats counts the lookups of a Cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the share of lookups answered from the cache.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache is a ChainTracker keeping the merkle roots confirmed by another
+// chain tracker, and sending identical concurrent lookups to it once.
+//
+// Only confirmed merkle roots are kept, since a block unknown to the chain
+// tracker may be known later. Those of blocks near the tip of the chain
+// expire after TipTTL, so that a reorganization is noticed.
+type Cache struct {
+	ct    ChainTracker
+	opts  CacheOptions
+	group singleflight.Group
+	now   func() time.Time
+
+	mu         sync.Mutex
+	entries    map[cacheKey]*list.Element
+	lru        *list.List
+	tip        uint32
+	tipExpires time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheKey struct {
+	root   chainhash.Hash
+	height uint32
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	expires time.Time
+}
+
+// NewCache wraps ct in a Cache.
+func NewCache(ct ChainTracker, opts CacheOptions) *Cache {
+	if opts.Size <= 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.TipDepth == 0 {
+		opts.TipDepth = DefaultCacheTipDepth
+	}
+	if opts.TipTTL <= 0 {
+		opts.TipTTL = DefaultCacheTipTTL
+	}
+	return &Cache{
+		ct:      ct,
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[cacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// IsValidRootForHeight answers from the cache when the merkle root was
+// confirmed before, and asks the wrapped chain tracker otherwise.
+func (c *Cache) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	key := cacheKey{root: *root, height: height}
+	if c.get(key) {
+		c.hits.Add(1)
+		if c.opts.OnHit != nil {
+			c.opts.OnHit(root, height)
+		}
+		return true, nil
+	}
+	c.misses.Add(1)
+	if c.opts.OnMiss != nil {
+		c.opts.OnMiss(root, height)
+	}
+
+	ch := c.group.DoChan(fmt.Sprintf("root:%s:%d", root, height), func() (any, error) {
+		// A lookup done since the cache was checked may have kept the root.
+		if c.get(key) {
+			return true, nil
+		}
+		valid, err := c.ct.IsValidRootForHeight(context.WithoutCancel(ctx), root, height)
+		if err == nil && valid {
+			c.put(ctx, key)
+		}
+		return valid, err
+	})
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return false, res.Err
+		}
+		return res.Val.(bool), nil
+	}
+}
+
+// CurrentHeight asks the wrapped chain tracker for the height of the tip,
+// sharing the request with concurrent callers.
+func (c *Cache) CurrentHeight(ctx context.Context) (uint32, error) {
+	ch := c.group.DoChan("tip", func() (any, error) {
+		height, err := c.ct.CurrentHeight(context.WithoutCancel(ctx))
+		if err == nil {
+			c.mu.Lock()
+			c.tip = height
+			c.tipExpires = c.now().Add(c.opts.TipTTL)
+			c.mu.Unlock()
+		}
+		return height, err
+	})
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return 0, res.Err
+		}
+		return res.Val.(uint32), nil
+	}
+}
+
+// Stats returns the number of lookups answered from the cache and from the
+// wrapped chain tracker so far.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Len returns the number of merkle roots kept.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge drops every merkle root kept, for instance after a reorganization.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]*list.Element)
+	c.lru.Init()
+	c.tipExpires = time.Time{}
+}
+
+func (c *Cache) get(key cacheKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return false
+	}
+	c.lru.MoveToFront(elem)
+	return true
+}
+
+func (c *Cache) put(ctx context.Context, key cacheKey) {
+	var expires time.Time
+	if tip, ok := c.currentTip(ctx); !ok || key.height+c.opts.TipDepth > tip {
+		expires = c.now().Add(c.opts.TipTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).expires = expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, expires: expires})
+	for c.lru.Len() > c.opts.Size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// currentTip returns the height of the tip, asking the wrapped chain
+// tracker once the last known height expired. It reports false when the
+// height is unknown, in which case every block is taken as near the tip.
+func (c *Cache) currentTip(ctx context.Context) (uint32, bool) {
+	c.mu.Lock()
+	tip, fresh := c.tip, c.now().Before(c.tipExpires)
+	c.mu.Unlock()
+	if fresh {
+		return tip, true
+	}
+	tip, err := c.CurrentHeight(context.WithoutCancel(ctx))
+	return tip, err == nil
+}
@@ -0,0 +1,210 @@
+package chaintracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTracker confirms the merkle roots it holds, counting lookups and
+// optionally holding them until release is closed.
+type countingTracker struct {
+	roots   map[uint32]chainhash.Hash
+	tip     uint32
+	err     error
+	release chan struct{}
+	lookups atomic.Int32
+}
+
+func (c *countingTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	c.lookups.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	if c.err != nil {
+		return false, c.err
+	}
+	valid, ok := c.roots[height]
+	return ok && valid == *root, nil
+}
+
+func (c *countingTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	return c.tip, nil
+}
+
+func testRoot(height uint32) *chainhash.Hash {
+	h := chainhash.DoubleHashH([]byte{byte(height), byte(height >> 8)})
+	return &h
+}
+
+func newCountingTracker(tip uint32) *countingTracker {
+	ct := &countingTracker{roots: make(map[uint32]chainhash.Hash), tip: tip}
+	for height := uint32(0); height <= tip; height++ {
+		ct.roots[height] = *testRoot(height)
+	}
+	return ct
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("answers confirmed merkle roots from the cache", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		var hits, misses int
+		cache := NewCache(ct, CacheOptions{
+			OnHit:  func(*chainhash.Hash, uint32) { hits++ },
+			OnMiss: func(*chainhash.Hash, uint32) { misses++ },
+		})
+		var _ ChainTracker = cache
+
+		for i := 0; i < 3; i++ {
+			valid, err := cache.IsValidRootForHeight(ctx, testRoot(50), 50)
+			require.NoError(t, err)
+			require.True(t, valid)
+		}
+		require.Equal(t, int32(1), ct.lookups.Load())
+		require.Equal(t, 2, hits)
+		require.Equal(t, 1, misses)
+		require.Equal(t, CacheStats{Hits: 2, Misses: 1}, cache.Stats())
+		require.InDelta(t, 2.0/3, cache.Stats().HitRate(), 1e-9)
+	})
+
+	t.Run("does not keep merkle roots which are not valid", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		cache := NewCache(ct, CacheOptions{})
+		for i := 0; i < 2; i++ {
+			valid, err := cache.IsValidRootForHeight(ctx, testRoot(51), 50)
+			require.NoError(t, err)
+			require.False(t, valid)
+		}
+		require.Equal(t, int32(2), ct.lookups.Load())
+		require.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("does not keep errors", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		ct.err = errors.New("unavailable")
+		cache := NewCache(ct, CacheOptions{})
+		_, err := cache.IsValidRootForHeight(ctx, testRoot(50), 50)
+		require.ErrorIs(t, err, ct.err)
+
+		ct.err = nil
+		valid, err := cache.IsValidRootForHeight(ctx, testRoot(50), 50)
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("evicts the least recently used merkle root", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		cache := NewCache(ct, CacheOptions{Size: 2})
+		for _, height := range []uint32{10, 20, 10, 30} {
+			_, err := cache.IsValidRootForHeight(ctx, testRoot(height), height)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, cache.Len())
+		require.Equal(t, int32(3), ct.lookups.Load())
+
+		_, err := cache.IsValidRootForHeight(ctx, testRoot(10), 10)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), ct.lookups.Load())
+		_, err = cache.IsValidRootForHeight(ctx, testRoot(20), 20)
+		require.NoError(t, err)
+		require.Equal(t, int32(4), ct.lookups.Load())
+	})
+
+	t.Run("coalesces concurrent lookups", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		ct.release = make(chan struct{})
+		var missed sync.WaitGroup
+		missed.Add(10)
+		cache := NewCache(ct, CacheOptions{
+			OnMiss: func(*chainhash.Hash, uint32) { missed.Done() },
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				valid, err := cache.IsValidRootForHeight(ctx, testRoot(50), 50)
+				require.NoError(t, err)
+				require.True(t, valid)
+			}()
+		}
+		// Lookups either join the pending one, or find the root it kept.
+		missed.Wait()
+		close(ct.release)
+		wg.Wait()
+		require.Equal(t, int32(1), ct.lookups.Load())
+		require.Equal(t, uint64(10), cache.Stats().Misses)
+	})
+
+	t.Run("returns when the context is done", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		ct.release = make(chan struct{})
+		defer close(ct.release)
+		cache := NewCache(ct, CacheOptions{})
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := cache.IsValidRootForHeight(ctx, testRoot(50), 50)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("expires merkle roots near the tip", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		cache := NewCache(ct, CacheOptions{TipDepth: 6, TipTTL: time.Minute})
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for _, height := range []uint32{94, 95} {
+			_, err := cache.IsValidRootForHeight(ctx, testRoot(height), height)
+			require.NoError(t, err)
+		}
+		now = now.Add(2 * time.Minute)
+		for _, height := range []uint32{94, 95} {
+			_, err := cache.IsValidRootForHeight(ctx, testRoot(height), height)
+			require.NoError(t, err)
+		}
+		// Only the block within 6 blocks of the tip was looked up again.
+		require.Equal(t, int32(3), ct.lookups.Load())
+	})
+
+	t.Run("expires merkle roots when the tip is unknown", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		cache := NewCache(FromLegacy(legacyTracker{ct}), CacheOptions{TipTTL: time.Minute})
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			_, err := cache.IsValidRootForHeight(ctx, testRoot(10), 10)
+			require.NoError(t, err)
+			now = now.Add(2 * time.Minute)
+		}
+		require.Equal(t, int32(2), ct.lookups.Load())
+	})
+
+	t.Run("purges every merkle root", func(t *testing.T) {
+		ct := newCountingTracker(100)
+		cache := NewCache(ct, CacheOptions{})
+		_, err := cache.IsValidRootForHeight(ctx, testRoot(10), 10)
+		require.NoError(t, err)
+		cache.Purge()
+		require.Equal(t, 0, cache.Len())
+	})
+}
+
+type legacyTracker struct {
+	ct *countingTracker
+}
+
+func (l legacyTracker) IsValidRootForHeight(root *chainhash.Hash, height uint32) bool {
+	valid, _ := l.ct.IsValidRootForHeight(context.Background(), root, height)
+	return valid
+}
@@ -1,7 +1,51 @@
 package chaintracker
 
-import "github.com/bitcoin-sv/go-sdk/chainhash"
+import (
+	"context"
+	"errors"
 
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+// ErrCurrentHeightUnsupported is returned by CurrentHeight when the chain
+// tracker can not tell the current height of the chain.
+var ErrCurrentHeightUnsupported = errors.New("chain tracker does not report the current height")
+
+// ChainTracker confirms merkle roots against the block headers of the chain.
+//
+// An error means the chain tracker could not answer, for instance because a
+// service is unreachable, and must not be taken as the root being invalid.
 type ChainTracker interface {
+	// IsValidRootForHeight reports whether root is the merkle root of the
+	// block at the given height.
+	IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error)
+	// CurrentHeight returns the height of the tip of the chain.
+	CurrentHeight(ctx context.Context) (uint32, error)
+}
+
+// LegacyChainTracker is the former ChainTracker interface, which can neither
+// take a context nor report an error.
+type LegacyChainTracker interface {
 	IsValidRootForHeight(root *chainhash.Hash, height uint32) bool
 }
+
+// FromLegacy adapts a LegacyChainTracker to the ChainTracker interface. Its
+// CurrentHeight returns ErrCurrentHeightUnsupported.
+func FromLegacy(ct LegacyChainTracker) ChainTracker {
+	return &legacyChainTracker{ct}
+}
+
+type legacyChainTracker struct {
+	ct LegacyChainTracker
+}
+
+func (l *legacyChainTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return l.ct.IsValidRootForHeight(root, height), nil
+}
+
+func (l *legacyChainTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	return 0, ErrCurrentHeightUnsupported
+}
@@ -0,0 +1,41 @@
+package headerchain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/block"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddWriteFailure(t *testing.T) {
+	bits := uint32(0x207fffff)
+	limit := block.CompactToBig(bits)
+	base := &block.Header{Version: 1, Timestamp: 1231006505, Bits: bits}
+	header := &block.Header{Version: 1, PrevHash: *base.Hash(), Timestamp: base.Timestamp + 600, Bits: bits}
+	for header.CheckProofOfWorkLimit(limit) != nil {
+		header.Nonce++
+	}
+
+	path := filepath.Join(t.TempDir(), "headers")
+	chain, err := OpenWithPowLimit(path, base, 0, limit)
+	require.NoError(t, err)
+	defer chain.Close()
+
+	// A header failing to be written is not added.
+	file := chain.file
+	chain.file, err = os.Open(path)
+	require.NoError(t, err)
+	require.Error(t, chain.Add(header))
+	require.NoError(t, chain.file.Close())
+	tip, height := chain.Tip()
+	require.Equal(t, base, tip)
+	require.Zero(t, height)
+	require.NotContains(t, chain.entries, *header.Hash())
+
+	chain.file = file
+	require.NoError(t, chain.Add(header))
+	tip, _ = chain.Tip()
+	require.Equal(t, header, tip)
+}
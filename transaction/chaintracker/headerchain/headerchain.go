@@ -0,0 +1,247 @@
+// Package headerchain implements a ChainTracker from a local chain of block
+// headers, allowing transactions to be verified without any service.
+//
+// Headers are only accepted when they link to a known header and carry a
+// valid proof of work. The chain with the most cumulative work is the active
+// chain, so a competing branch overtaking it reorganizes the chain. Difficulty
+// adjustments are not checked, the chain should thus be fed from a trusted
+// source or anchored at a recent checkpoint.
+package headerchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/bitcoin-sv/go-sdk/block"
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+var (
+	// ErrUnknownPrevHeader is returned when adding a header which does not
+	// link to a header of the chain.
+	ErrUnknownPrevHeader = errors.New("header links to an unknown header")
+	// ErrHeightNotFound is returned when the active chain has no header at
+	// the requested height.
+	ErrHeightNotFound = errors.New("no header at height")
+)
+
+// Chain is a tree of block headers rooted at a checkpoint, tracking the
+// branch with the most work as the active chain. It is safe for concurrent
+// use.
+type Chain struct {
+	mu       sync.RWMutex
+	powLimit *big.Int
+	base     uint32
+	entries  map[chainhash.Hash]*entry
+	active   []*entry
+	file     *os.File
+}
+
+type entry struct {
+	header *block.Header
+	height uint32
+	work   *big.Int
+	prev   *entry
+}
+
+// New creates a chain starting at the checkpoint header at the given height.
+// The checkpoint is trusted as is, headers added afterwards must have a
+// proof of work no easier than block.MaxTarget.
+func New(checkpoint *block.Header, height uint32) *Chain {
+	return NewWithPowLimit(checkpoint, height, block.MaxTarget)
+}
+
+// NewWithPowLimit is like New with the easiest target allowed given, for
+// networks such as regtest.
+func NewWithPowLimit(checkpoint *block.Header, height uint32, powLimit *big.Int) *Chain {
+	root := &entry{
+		header: checkpoint,
+		height: height,
+		work:   checkpoint.Work(),
+	}
+	return &Chain{
+		powLimit: powLimit,
+		base:     height,
+		entries:  map[chainhash.Hash]*entry{*checkpoint.Hash(): root},
+		active:   []*entry{root},
+	}
+}
+
+// Open creates a chain starting at the checkpoint header and loads the
+// headers of the file at path, which is created if needed. Headers accepted
+// by Add are appended to the file, so that the chain is restored when the
+// file is opened again. The file holds the raw 80 byte headers following the
+// checkpoint, in the order they were added. Close releases the file.
+func Open(path string, checkpoint *block.Header, height uint32) (*Chain, error) {
+	return OpenWithPowLimit(path, checkpoint, height, block.MaxTarget)
+}
+
+// OpenWithPowLimit is like Open with the easiest target allowed given.
+func OpenWithPowLimit(path string, checkpoint *block.Header, height uint32, powLimit *big.Int) (*Chain, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c := NewWithPowLimit(checkpoint, height, powLimit)
+	for {
+		header := &block.Header{}
+		if _, err := header.ReadFrom(file); err == io.EOF {
+			break
+		} else if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		if err := c.add(header); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+	}
+	c.file = file
+	return c, nil
+}
+
+// Close releases the file of a chain created with Open.
+func (c *Chain) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Add adds headers to the chain, each one linking to the checkpoint or a
+// previously added header. Headers already known are skipped. Adding stops
+// at the first invalid header, or the first one failing to be written to
+// the file, which is then left out of the chain.
+func (c *Chain) Add(headers ...*block.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, header := range headers {
+		if _, ok := c.entries[*header.Hash()]; ok {
+			continue
+		}
+		e, err := c.entry(header)
+		if err != nil {
+			return err
+		}
+		if err := c.write(header); err != nil {
+			return err
+		}
+		c.link(e)
+	}
+	return nil
+}
+
+// write appends the header to the file, if any, truncating what was
+// written of it on failure so that the file stays loadable.
+func (c *Chain) write(header *block.Header) error {
+	if c.file == nil {
+		return nil
+	}
+	offset, err := c.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := header.WriteTo(c.file); err != nil {
+		if truncErr := c.file.Truncate(offset); truncErr == nil {
+			_, _ = c.file.Seek(offset, io.SeekStart)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Chain) add(header *block.Header) error {
+	if _, ok := c.entries[*header.Hash()]; ok {
+		return nil
+	}
+	e, err := c.entry(header)
+	if err != nil {
+		return err
+	}
+	c.link(e)
+	return nil
+}
+
+// entry validates the header, returning its entry in the chain.
+func (c *Chain) entry(header *block.Header) (*entry, error) {
+	prev, ok := c.entries[header.PrevHash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPrevHeader, header.PrevHash)
+	}
+	if err := header.CheckProofOfWorkLimit(c.powLimit); err != nil {
+		return nil, err
+	}
+	return &entry{
+		header: header,
+		height: prev.height + 1,
+		work:   new(big.Int).Add(prev.work, header.Work()),
+		prev:   prev,
+	}, nil
+}
+
+// link adds the entry to the chain, reorganizing the active chain when its
+// branch has the most work.
+func (c *Chain) link(e *entry) {
+	c.entries[*e.header.Hash()] = e
+
+	tip := c.active[len(c.active)-1]
+	if e.work.Cmp(tip.work) <= 0 {
+		return
+	}
+	// The new header extends the branch with the most work. When it does not
+	// extend the active chain, the active chain is replaced from the point
+	// where the branches fork.
+	if n := int(e.height-c.base) + 1; n > len(c.active) {
+		c.active = append(c.active, make([]*entry, n-len(c.active))...)
+	} else {
+		c.active = c.active[:n]
+	}
+	for fork := e; fork != nil && c.active[fork.height-c.base] != fork; fork = fork.prev {
+		c.active[fork.height-c.base] = fork
+	}
+}
+
+// Tip returns the last header of the active chain and its height.
+func (c *Chain) Tip() (*block.Header, uint32) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	tip := c.active[len(c.active)-1]
+	return tip.header, tip.height
+}
+
+// HeaderAtHeight returns the header of the active chain at the given height.
+func (c *Chain) HeaderAtHeight(height uint32) (*block.Header, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height < c.base || int(height-c.base) >= len(c.active) {
+		return nil, fmt.Errorf("%w %d", ErrHeightNotFound, height)
+	}
+	return c.active[height-c.base].header, nil
+}
+
+// IsValidRootForHeight reports whether the header of the active chain at
+// the given height has the given merkle root. It fails with
+// ErrHeightNotFound for a height out of the chain, such as one it does not
+// reach yet, as the chain can not tell whether the root is valid.
+func (c *Chain) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	header, err := c.HeaderAtHeight(height)
+	if err != nil {
+		return false, err
+	}
+	return header.MerkleRoot.IsEqual(root), nil
+}
+
+// CurrentHeight returns the height of the tip of the active chain.
+func (c *Chain) CurrentHeight(ctx context.Context) (uint32, error) {
+	_, height := c.Tip()
+	return height, nil
+}
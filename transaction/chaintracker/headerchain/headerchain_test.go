@@ -0,0 +1,166 @@
+package headerchain_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/block"
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker/headerchain"
+	"github.com/stretchr/testify/require"
+)
+
+// regtestBits is the easiest target of regtest, found in about two tries.
+const regtestBits = 0x207fffff
+
+var regtestLimit = block.CompactToBig(regtestBits)
+
+// mine returns a header with a valid proof of work following prev, whose
+// merkle root is derived from tag so that branches differ.
+func mine(t *testing.T, prev *block.Header, bits uint32, tag byte) *block.Header {
+	header := &block.Header{
+		Version:    1,
+		PrevHash:   *prev.Hash(),
+		MerkleRoot: chainhash.DoubleHashH(append(prev.Hash().CloneBytes(), tag)),
+		Timestamp:  prev.Timestamp + 600,
+		Bits:       bits,
+	}
+	for header.CheckProofOfWorkLimit(regtestLimit) != nil {
+		header.Nonce++
+	}
+	return header
+}
+
+func mineBranch(t *testing.T, prev *block.Header, n int, bits uint32, tag byte) []*block.Header {
+	headers := make([]*block.Header, n)
+	for i := range headers {
+		headers[i] = mine(t, prev, bits, tag)
+		prev = headers[i]
+	}
+	return headers
+}
+
+func valid(t *testing.T, chain *headerchain.Chain, root *chainhash.Hash, height uint32) bool {
+	ok, err := chain.IsValidRootForHeight(context.Background(), root, height)
+	require.NoError(t, err)
+	return ok
+}
+
+func checkpoint() *block.Header {
+	return &block.Header{Version: 1, Timestamp: 1231006505, Bits: regtestBits}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tracks merkle roots of the active chain", func(t *testing.T) {
+		base := checkpoint()
+		chain := headerchain.NewWithPowLimit(base, 100, regtestLimit)
+		headers := mineBranch(t, base, 3, regtestBits, 0)
+		require.NoError(t, chain.Add(headers...))
+
+		tip, height := chain.Tip()
+		require.Equal(t, headers[2], tip)
+		require.Equal(t, uint32(103), height)
+		current, err := chain.CurrentHeight(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint32(103), current)
+
+		require.True(t, valid(t, chain, &base.MerkleRoot, 100))
+		require.True(t, valid(t, chain, &headers[1].MerkleRoot, 102))
+		require.False(t, valid(t, chain, &headers[1].MerkleRoot, 103))
+
+		// Heights the chain does not hold can not be verified.
+		for _, height := range []uint32{104, 99} {
+			_, err := chain.IsValidRootForHeight(context.Background(), &headers[1].MerkleRoot, height)
+			require.ErrorIs(t, err, headerchain.ErrHeightNotFound)
+		}
+
+		_, err = chain.HeaderAtHeight(104)
+		require.ErrorIs(t, err, headerchain.ErrHeightNotFound)
+
+		// Known headers are skipped.
+		require.NoError(t, chain.Add(headers...))
+	})
+
+	t.Run("rejects invalid headers", func(t *testing.T) {
+		base := checkpoint()
+		chain := headerchain.NewWithPowLimit(base, 0, regtestLimit)
+
+		orphan := mine(t, mine(t, base, regtestBits, 0), regtestBits, 0)
+		require.ErrorIs(t, chain.Add(orphan), headerchain.ErrUnknownPrevHeader)
+
+		header := mine(t, base, regtestBits, 0)
+		for header.CheckProofOfWorkLimit(regtestLimit) == nil {
+			header.Nonce++
+		}
+		require.ErrorIs(t, chain.Add(header), block.ErrProofOfWork)
+
+		// Mainnet does not accept the regtest difficulty.
+		mainnet := headerchain.New(base, 0)
+		require.ErrorIs(t, mainnet.Add(mine(t, base, regtestBits, 0)), block.ErrInvalidTarget)
+	})
+
+	t.Run("reorganizes to the branch with the most work", func(t *testing.T) {
+		base := checkpoint()
+		chain := headerchain.NewWithPowLimit(base, 0, regtestLimit)
+		main := mineBranch(t, base, 3, regtestBits, 0)
+		require.NoError(t, chain.Add(main...))
+
+		// A competing branch from height 1 with as much work does not
+		// replace the chain seen first.
+		fork := mineBranch(t, main[0], 2, regtestBits, 1)
+		require.NoError(t, chain.Add(fork...))
+		tip, height := chain.Tip()
+		require.Equal(t, main[2], tip)
+		require.Equal(t, uint32(3), height)
+
+		// Once it has more work it becomes the active chain.
+		fork = append(fork, mine(t, fork[1], regtestBits, 1))
+		require.NoError(t, chain.Add(fork[2]))
+		tip, height = chain.Tip()
+		require.Equal(t, fork[2], tip)
+		require.Equal(t, uint32(4), height)
+		require.True(t, valid(t, chain, &main[0].MerkleRoot, 1))
+		require.False(t, valid(t, chain, &main[1].MerkleRoot, 2))
+		require.True(t, valid(t, chain, &fork[0].MerkleRoot, 2))
+
+		// A shorter branch with a harder target can also win.
+		harder := mine(t, main[2], 0x1f7fffff, 2)
+		require.NoError(t, chain.Add(harder))
+		tip, height = chain.Tip()
+		require.Equal(t, harder, tip)
+		require.Equal(t, uint32(4), height)
+		require.True(t, valid(t, chain, &main[1].MerkleRoot, 2))
+	})
+
+	t.Run("restores the chain from its file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers")
+		base := checkpoint()
+		chain, err := headerchain.OpenWithPowLimit(path, base, 10, regtestLimit)
+		require.NoError(t, err)
+		main := mineBranch(t, base, 2, regtestBits, 0)
+		fork := mineBranch(t, base, 3, regtestBits, 1)
+		require.NoError(t, chain.Add(main...))
+		require.NoError(t, chain.Add(fork...))
+		require.NoError(t, chain.Close())
+
+		chain, err = headerchain.OpenWithPowLimit(path, base, 10, regtestLimit)
+		require.NoError(t, err)
+		defer chain.Close()
+		tip, height := chain.Tip()
+		require.Equal(t, fork[2], tip)
+		require.Equal(t, uint32(13), height)
+
+		// Headers keep being appended after the restored ones.
+		next := mine(t, fork[2], regtestBits, 1)
+		require.NoError(t, chain.Add(next))
+		require.NoError(t, chain.Close())
+		chain, err = headerchain.OpenWithPowLimit(path, base, 10, regtestLimit)
+		require.NoError(t, err)
+		_, height = chain.Tip()
+		require.Equal(t, uint32(14), height)
+		require.NoError(t, chain.Close())
+	})
+}
@@ -0,0 +1,221 @@
+// Package headersclient implements a chain tracker backed by a Block Headers
+// Service, which keeps the headers of the chain and confirms merkle roots
+// against them.
+package headersclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+var (
+	// ErrUnauthorized is returned when the service rejects the API key.
+	ErrUnauthorized = errors.New("block headers service rejected the api key")
+	// ErrUnexpectedStatus is returned when the service answers with a status
+	// other than 200 OK.
+	ErrUnexpectedStatus = errors.New("unexpected block headers service response status")
+	// ErrUnexpectedResponse is returned when the service response does not
+	// match the request.
+	ErrUnexpectedResponse = errors.New("unexpected block headers service response")
+	// ErrUnableToVerify is returned when the service can not tell whether a
+	// merkle root is valid, such as one of a block it has not synced yet,
+	// or reports a confirmation state this client does not know.
+	ErrUnableToVerify = errors.New("block headers service unable to verify the merkle root")
+)
+
+// Confirmation states reported by the service for a merkle root.
+const (
+	Confirmed      = "CONFIRMED"
+	Invalid        = "INVALID"
+	UnableToVerify = "UNABLE_TO_VERIFY"
+)
+
+const (
+	verifyEndpoint  = "/api/v1/chain/merkleroot/verify"
+	longestEndpoint = "/api/v1/chain/tip/longest"
+)
+
+// Client is a chain tracker querying a Block Headers Service.
+type Client struct {
+	// Url is the base url of the service, without a trailing slash.
+	Url string
+	// ApiKey is sent as a bearer token when not empty.
+	ApiKey string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// New returns a Client for the service at url.
+func New(url string, apiKey string) *Client {
+	return &Client{
+		Url:    url,
+		ApiKey: apiKey,
+	}
+}
+
+// MerkleRoot is a merkle root along with the height of its block.
+type MerkleRoot struct {
+	MerkleRoot  *chainhash.Hash
+	BlockHeight uint32
+}
+
+type merkleRootRequest struct {
+	MerkleRoot  string `json:"merkleRoot"`
+	BlockHeight uint32 `json:"blockHeight"`
+}
+
+type merkleRootConfirmation struct {
+	MerkleRoot   string `json:"merkleRoot"`
+	BlockHeight  uint32 `json:"blockHeight"`
+	Hash         string `json:"hash,omitempty"`
+	Confirmation string `json:"confirmation"`
+}
+
+type merkleRootsResponse struct {
+	ConfirmationState string                   `json:"confirmationState"`
+	Confirmations     []merkleRootConfirmation `json:"confirmations"`
+}
+
+type tipResponse struct {
+	Height uint32 `json:"height"`
+	State  string `json:"state,omitempty"`
+}
+
+// IsValidRootForHeight reports whether root is the merkle root of the block
+// at the given height. It fails with ErrUnableToVerify when the service can
+// not tell.
+func (c *Client) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	valid, err := c.VerifyMerkleRoots(ctx, []MerkleRoot{{MerkleRoot: root, BlockHeight: height}})
+	if err != nil {
+		return false, err
+	}
+	return valid[0], nil
+}
+
+// VerifyMerkleRoots checks every merkle root against the block at its
+// height in a single request, returning whether each of them is confirmed
+// in the same order. It fails with ErrUnableToVerify when the service can
+// not tell for one of them, such as a root of a block it does not know.
+func (c *Client) VerifyMerkleRoots(ctx context.Context, roots []MerkleRoot) ([]bool, error) {
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	payload := make([]merkleRootRequest, 0, len(roots))
+	for _, root := range roots {
+		payload = append(payload, merkleRootRequest{
+			MerkleRoot:  root.MerkleRoot.String(),
+			BlockHeight: root.BlockHeight,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &merkleRootsResponse{}
+	if err := c.do(ctx, http.MethodPost, verifyEndpoint, body, response); err != nil {
+		return nil, err
+	}
+
+	valid := make([]bool, len(roots))
+	if len(response.Confirmations) == 0 {
+		// Older versions of the service only report the state of the
+		// whole batch, which is confirmed when every root is.
+		confirmed, err := isConfirmed(response.ConfirmationState)
+		if err != nil {
+			return nil, err
+		}
+		for i := range valid {
+			valid[i] = confirmed
+		}
+		return valid, nil
+	}
+	if len(response.Confirmations) != len(roots) {
+		return nil, fmt.Errorf("%w: %d confirmations for %d merkle roots", ErrUnexpectedResponse, len(response.Confirmations), len(roots))
+	}
+	for i, confirmation := range response.Confirmations {
+		if confirmation.BlockHeight != roots[i].BlockHeight || confirmation.MerkleRoot != payload[i].MerkleRoot {
+			return nil, fmt.Errorf("%w: confirmation %d is for merkle root %s at height %d", ErrUnexpectedResponse, i, confirmation.MerkleRoot, confirmation.BlockHeight)
+		}
+		if valid[i], err = isConfirmed(confirmation.Confirmation); err != nil {
+			return nil, fmt.Errorf("merkle root %s at height %d: %w", confirmation.MerkleRoot, confirmation.BlockHeight, err)
+		}
+	}
+	return valid, nil
+}
+
+// isConfirmed maps a confirmation state to the validity of the merkle root,
+// only INVALID meaning that it is not valid.
+func isConfirmed(state string) (bool, error) {
+	switch state {
+	case Confirmed:
+		return true, nil
+	case Invalid:
+		return false, nil
+	case UnableToVerify:
+		return false, ErrUnableToVerify
+	default:
+		return false, fmt.Errorf("%w: unknown confirmation state %q", ErrUnableToVerify, state)
+	}
+}
+
+// CurrentHeight returns the height of the tip of the longest chain known to
+// the service.
+func (c *Client) CurrentHeight(ctx context.Context) (uint32, error) {
+	response := &tipResponse{}
+	if err := c.do(ctx, http.MethodGet, longestEndpoint, nil, response); err != nil {
+		return 0, err
+	}
+	return response.Height, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint string, body []byte, response any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.Url+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+	default:
+		return fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, resp.Status, bytes.TrimSpace(msg))
+	}
+	if err := json.Unmarshal(msg, response); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnexpectedResponse, err)
+	}
+	return nil
+}
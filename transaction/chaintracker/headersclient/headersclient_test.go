@@ -0,0 +1,177 @@
+package headersclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker/headersclient"
+	"github.com/stretchr/testify/require"
+)
+
+const apiKey = "secret"
+
+func root(tag byte) *chainhash.Hash {
+	h := chainhash.DoubleHashH([]byte{tag})
+	return &h
+}
+
+func TestClient(t *testing.T) {
+	var _ chaintracker.ChainTracker = (*headersclient.Client)(nil)
+
+	server := headersclient.NewTestServer(apiKey, map[uint32]*chainhash.Hash{
+		100: root(1),
+		101: root(2),
+	})
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	t.Run("confirms the merkle root of a block", func(t *testing.T) {
+		valid, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("rejects the merkle root of another block", func(t *testing.T) {
+		valid, err := client.IsValidRootForHeight(ctx, root(2), 100)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+
+	t.Run("can not verify merkle roots of unknown blocks", func(t *testing.T) {
+		_, err := client.IsValidRootForHeight(ctx, root(3), 102)
+		require.ErrorIs(t, err, headersclient.ErrUnableToVerify)
+
+		_, err = client.VerifyMerkleRoots(ctx, []headersclient.MerkleRoot{
+			{MerkleRoot: root(1), BlockHeight: 100},
+			{MerkleRoot: root(3), BlockHeight: 102},
+		})
+		require.ErrorIs(t, err, headersclient.ErrUnableToVerify)
+	})
+
+	t.Run("verifies merkle roots in a batch", func(t *testing.T) {
+		valid, err := client.VerifyMerkleRoots(ctx, []headersclient.MerkleRoot{
+			{MerkleRoot: root(1), BlockHeight: 100},
+			{MerkleRoot: root(1), BlockHeight: 101},
+			{MerkleRoot: root(2), BlockHeight: 101},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false, true}, valid)
+	})
+
+	t.Run("returns the current height", func(t *testing.T) {
+		height, err := client.CurrentHeight(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint32(101), height)
+
+		server.AddMerkleRoot(102, root(3))
+		height, err = client.CurrentHeight(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint32(102), height)
+	})
+
+	t.Run("returns an error for a wrong api key", func(t *testing.T) {
+		client := server.Client()
+		client.ApiKey = "wrong"
+		_, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.ErrorIs(t, err, headersclient.ErrUnauthorized)
+		_, err = client.CurrentHeight(ctx)
+		require.ErrorIs(t, err, headersclient.ErrUnauthorized)
+	})
+
+	t.Run("returns an error when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestClientResponses(t *testing.T) {
+	ctx := context.Background()
+	serve := func(t *testing.T, status int, body string) *headersclient.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return headersclient.New(server.URL, apiKey)
+	}
+
+	t.Run("accepts a batch confirmation state", func(t *testing.T) {
+		client := serve(t, http.StatusOK, `{"confirmationState":"CONFIRMED"}`)
+		valid, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("maps confirmation states", func(t *testing.T) {
+		for _, tt := range []struct {
+			state string
+			valid bool
+			err   error
+		}{
+			{headersclient.Confirmed, true, nil},
+			{headersclient.Invalid, false, nil},
+			{headersclient.UnableToVerify, false, headersclient.ErrUnableToVerify},
+			{"PENDING", false, headersclient.ErrUnableToVerify},
+		} {
+			bodies := []string{
+				fmt.Sprintf(`{"confirmationState":%q}`, tt.state),
+				fmt.Sprintf(`{"confirmationState":%q,"confirmations":[{"merkleRoot":%q,"blockHeight":100,"confirmation":%q}]}`, tt.state, root(1), tt.state),
+			}
+			for _, body := range bodies {
+				client := serve(t, http.StatusOK, body)
+				valid, err := client.IsValidRootForHeight(ctx, root(1), 100)
+				require.ErrorIs(t, err, tt.err, body)
+				require.Equal(t, tt.valid, valid, body)
+
+				batch, err := client.VerifyMerkleRoots(ctx, []headersclient.MerkleRoot{{MerkleRoot: root(1), BlockHeight: 100}})
+				require.ErrorIs(t, err, tt.err, body)
+				if tt.err == nil {
+					require.Equal(t, []bool{tt.valid}, batch, body)
+				}
+			}
+		}
+	})
+
+	t.Run("maps error statuses to errors", func(t *testing.T) {
+		client := serve(t, http.StatusInternalServerError, "database unavailable")
+		_, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.ErrorIs(t, err, headersclient.ErrUnexpectedStatus)
+		require.ErrorContains(t, err, "database unavailable")
+	})
+
+	t.Run("rejects malformed responses", func(t *testing.T) {
+		client := serve(t, http.StatusOK, "<html>")
+		_, err := client.CurrentHeight(ctx)
+		require.ErrorIs(t, err, headersclient.ErrUnexpectedResponse)
+	})
+
+	t.Run("rejects confirmations not matching the request", func(t *testing.T) {
+		client := serve(t, http.StatusOK, `{"confirmationState":"CONFIRMED","confirmations":[{"merkleRoot":"00","blockHeight":100,"confirmation":"CONFIRMED"}]}`)
+		_, err := client.IsValidRootForHeight(ctx, root(1), 100)
+		require.ErrorIs(t, err, headersclient.ErrUnexpectedResponse)
+	})
+}
+
+func TestClientVerifiesMerklePath(t *testing.T) {
+	txids := []*chainhash.Hash{root(1), root(2), root(3)}
+	merkleRoot, err := transaction.MerkleRootFromTxids(txids)
+	require.NoError(t, err)
+	path, err := transaction.NewMerklePathFromBlockTxids(800000, txids, txids[1])
+	require.NoError(t, err)
+
+	server := headersclient.NewTestServer(apiKey, map[uint32]*chainhash.Hash{800000: merkleRoot})
+	defer server.Close()
+
+	valid, err := path.VerifyContext(context.Background(), txids[1], server.Client())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
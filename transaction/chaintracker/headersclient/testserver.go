@@ -0,0 +1,107 @@
+package headersclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+// TestServer is an in-memory Block Headers Service for tests, answering the
+// requests of a Client from the merkle roots it is given.
+type TestServer struct {
+	*httptest.Server
+	// ApiKey is the bearer token expected from clients, none when empty.
+	ApiKey string
+
+	mu     sync.Mutex
+	roots  map[uint32]chainhash.Hash
+	height uint32
+}
+
+// NewTestServer starts a TestServer knowing the merkle root of each block
+// height in roots. Close it once done.
+func NewTestServer(apiKey string, roots map[uint32]*chainhash.Hash) *TestServer {
+	s := &TestServer{
+		ApiKey: apiKey,
+		roots:  make(map[uint32]chainhash.Hash, len(roots)),
+	}
+	for height, root := range roots {
+		s.AddMerkleRoot(height, root)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+verifyEndpoint, s.verify)
+	mux.HandleFunc("GET "+longestEndpoint, s.tip)
+	s.Server = httptest.NewServer(s.authorize(mux))
+	return s
+}
+
+// Client returns a Client of the server, using its ApiKey.
+func (s *TestServer) Client() *Client {
+	return &Client{
+		Url:    s.URL,
+		ApiKey: s.ApiKey,
+		Client: s.Server.Client(),
+	}
+}
+
+// AddMerkleRoot makes root the merkle root of the block at height. The tip
+// is the highest block known.
+func (s *TestServer) AddMerkleRoot(height uint32, root *chainhash.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots[height] = *root
+	s.height = max(s.height, height)
+}
+
+func (s *TestServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.ApiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.ApiKey {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *TestServer) verify(w http.ResponseWriter, r *http.Request) {
+	var request []merkleRootRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	response := merkleRootsResponse{ConfirmationState: Confirmed}
+	for _, req := range request {
+		confirmation := merkleRootConfirmation{
+			MerkleRoot:   req.MerkleRoot,
+			BlockHeight:  req.BlockHeight,
+			Confirmation: Confirmed,
+		}
+		if root, ok := s.roots[req.BlockHeight]; !ok {
+			confirmation.Confirmation = UnableToVerify
+		} else if root.String() != req.MerkleRoot {
+			confirmation.Confirmation = Invalid
+		}
+		if confirmation.Confirmation != Confirmed && response.ConfirmationState != Invalid {
+			response.ConfirmationState = confirmation.Confirmation
+		}
+		response.Confirmations = append(response.Confirmations, confirmation)
+	}
+	writeJSON(w, response)
+}
+
+func (s *TestServer) tip(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, tipResponse{Height: s.height, State: "LONGEST_CHAIN"})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
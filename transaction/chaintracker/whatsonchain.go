@@ -0,0 +1,110 @@
+package chaintracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction/whatsonchain"
+)
+
+// ErrWhatsOnChainNotFound is returned when WhatsOnChain does not know the
+// resource requested, such as the header of a block it has not seen yet.
+var ErrWhatsOnChainNotFound = errors.New("whatsonchain: not found")
+
+// WhatsOnChain is a chain tracker querying the block headers known to the
+// WhatsOnChain API.
+type WhatsOnChain struct {
+	Network whatsonchain.Network
+	// ApiKey is sent in the Authorization header when not empty.
+	ApiKey string
+	// BaseUrl replaces whatsonchain.Url when not empty.
+	BaseUrl string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// NewWhatsOnChain returns a WhatsOnChain chain tracker for the network.
+func NewWhatsOnChain(network whatsonchain.Network, apiKey string) *WhatsOnChain {
+	return &WhatsOnChain{
+		Network: network,
+		ApiKey:  apiKey,
+	}
+}
+
+type wocBlockHeader struct {
+	Hash       string `json:"hash"`
+	Height     uint32 `json:"height"`
+	MerkleRoot string `json:"merkleroot"`
+}
+
+type wocChainInfo struct {
+	Blocks uint32 `json:"blocks"`
+}
+
+// IsValidRootForHeight reports whether root is the merkle root of the block
+// header at the given height. It fails with ErrWhatsOnChainNotFound for a
+// height WhatsOnChain does not know a block at yet.
+func (w *WhatsOnChain) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	header := &wocBlockHeader{}
+	if err := w.get(ctx, fmt.Sprintf("/block/%d/header", height), header); err != nil {
+		return false, err
+	}
+	return header.MerkleRoot == root.String(), nil
+}
+
+// CurrentHeight returns the height of the tip of the chain.
+func (w *WhatsOnChain) CurrentHeight(ctx context.Context) (uint32, error) {
+	info := &wocChainInfo{}
+	if err := w.get(ctx, "/chain/info", info); err != nil {
+		return 0, err
+	}
+	return info.Blocks, nil
+}
+
+// get decodes the response to a GET of the endpoint into v.
+func (w *WhatsOnChain) get(ctx context.Context, endpoint string, v any) error {
+	baseUrl := w.BaseUrl
+	if baseUrl == "" {
+		baseUrl = whatsonchain.Url
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s%s", baseUrl, w.Network, endpoint), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if w.ApiKey != "" {
+		req.Header.Set("Authorization", w.ApiKey)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrWhatsOnChainNotFound, endpoint)
+	default:
+		return fmt.Errorf("whatsonchain: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.Unmarshal(msg, v); err != nil {
+		return fmt.Errorf("whatsonchain: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package chaintracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
+	"github.com/bitcoin-sv/go-sdk/transaction/whatsonchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhatsOnChain(t *testing.T) {
+	root := chainhash.DoubleHashH([]byte("block"))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test/block/100/header", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"hash":       "00",
+			"height":     100,
+			"merkleroot": root.String(),
+		})
+	})
+	mux.HandleFunc("GET /test/block/{height}/header", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("GET /test/chain/info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain":"test","blocks":120,"headers":120}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	woc := chaintracker.NewWhatsOnChain(whatsonchain.TestNet, "key")
+	woc.BaseUrl = server.URL
+	woc.Client = server.Client()
+	var _ chaintracker.ChainTracker = woc
+	ctx := context.Background()
+
+	t.Run("confirms the merkle root of a block", func(t *testing.T) {
+		valid, err := woc.IsValidRootForHeight(ctx, &root, 100)
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("rejects another merkle root", func(t *testing.T) {
+		other := chainhash.DoubleHashH([]byte("other"))
+		valid, err := woc.IsValidRootForHeight(ctx, &other, 100)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+
+	t.Run("can not verify merkle roots of unknown blocks", func(t *testing.T) {
+		_, err := woc.IsValidRootForHeight(ctx, &root, 101)
+		require.ErrorIs(t, err, chaintracker.ErrWhatsOnChainNotFound)
+	})
+
+	t.Run("returns the current height", func(t *testing.T) {
+		height, err := woc.CurrentHeight(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint32(120), height)
+	})
+
+	t.Run("returns an error for a failing service", func(t *testing.T) {
+		main := chaintracker.NewWhatsOnChain(whatsonchain.MainNet, "key")
+		main.BaseUrl = server.URL
+		main.Client = server.Client()
+		_, err := main.IsValidRootForHeight(ctx, &root, 100)
+		require.ErrorIs(t, err, chaintracker.ErrWhatsOnChainNotFound)
+
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		}))
+		defer failing.Close()
+		main.BaseUrl = failing.URL
+		_, err = main.IsValidRootForHeight(ctx, &root, 100)
+		require.ErrorContains(t, err, "rate limited")
+	})
+}
@@ -0,0 +1,148 @@
+package coinselection
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+// DefaultMaxTries bounds the search of BranchAndBound when MaxTries is zero.
+const DefaultMaxTries = 100000
+
+// BranchAndBound searches for UTXOs funding the transaction without change,
+// saving the change outputs along with the fee they cost, and keeping the
+// wallet from splitting into small coins.
+//
+// A match may exceed the outputs and the fee by up to CostOfChange, the
+// excess going to the miners. When no match is found, the selection is left
+// to Fallback, or fails with ErrNoExactMatch when it is nil.
+type BranchAndBound struct {
+	// MaxTries bounds the number of selections tried, DefaultMaxTries when
+	// zero.
+	MaxTries int
+	// CostOfChange is the excess accepted on a match. When zero, it is the
+	// fee of the change outputs plus a satoshi for each of them.
+	CostOfChange uint64
+	Fallback     Strategy
+}
+
+type candidate struct {
+	utxo *transaction.UTXO
+	// value is the amount of the UTXO, less the fee of spending it.
+	value uint64
+}
+
+func (b BranchAndBound) Select(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	result, err := b.search(pool, tx, fees)
+	if err != nil || result != nil {
+		return result, err
+	}
+	if b.Fallback != nil {
+		return b.Fallback.Select(pool, tx, fees)
+	}
+	return nil, ErrNoExactMatch
+}
+
+func (b BranchAndBound) search(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	f, err := newFunding(tx, fees)
+	if err != nil {
+		return nil, err
+	}
+	baseFee, err := f.fee(nil, false)
+	if err != nil {
+		return nil, err
+	}
+	tolerance := b.CostOfChange
+	if tolerance == 0 && f.changeOutputs > 0 {
+		feeWithChange, err := f.fee(nil, true)
+		if err != nil {
+			return nil, err
+		}
+		tolerance = feeWithChange - baseFee + uint64(f.changeOutputs)
+	}
+	if f.base >= f.target+baseFee {
+		if f.base-f.target-baseFee <= tolerance {
+			return &Result{Fee: f.base - f.target}, nil
+		}
+		return nil, nil
+	}
+	need := f.target + baseFee - f.base
+
+	candidates := make([]candidate, 0, len(pool))
+	for _, utxo := range pool {
+		fee, err := f.fee([]*transaction.UTXO{utxo}, false)
+		if err != nil {
+			return nil, err
+		}
+		if cost := fee - baseFee; utxo.Satoshis > cost {
+			candidates = append(candidates, candidate{utxo: utxo, value: utxo.Satoshis - cost})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.value, a.value)
+	})
+	// remaining[i] is the value of the candidates from i on.
+	remaining := make([]uint64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + candidates[i].value
+	}
+	if remaining[0] < need {
+		return nil, fmt.Errorf("%w: %d satoshis missing", ErrInsufficientFunds, need-remaining[0])
+	}
+
+	maxTries := b.MaxTries
+	if maxTries <= 0 {
+		maxTries = DefaultMaxTries
+	}
+	tries := 0
+	selected := make([]*transaction.UTXO, 0, len(candidates))
+
+	// visit explores the selections of candidates from i on, on top of the
+	// selected ones worth value.
+	var visit func(i int, value uint64) (*Result, error)
+	visit = func(i int, value uint64) (*Result, error) {
+		if tries++; tries > maxTries {
+			return nil, nil
+		}
+		if value >= need {
+			if value-need > tolerance {
+				return nil, nil
+			}
+			// The fee of the inputs may not add up exactly, check the
+			// selection against the actual fee.
+			utxos := slices.Clone(selected)
+			fee, err := f.fee(utxos, false)
+			if err != nil {
+				return nil, err
+			}
+			total := f.base
+			for _, utxo := range utxos {
+				total += utxo.Satoshis
+			}
+			if total >= f.target+fee && total-f.target-fee <= tolerance {
+				return &Result{Inputs: utxos, Fee: total - f.target}, nil
+			}
+			return nil, nil
+		}
+		if i == len(candidates) || value+remaining[i] < need {
+			return nil, nil
+		}
+
+		selected = append(selected, candidates[i].utxo)
+		result, err := visit(i+1, value+candidates[i].value)
+		selected = selected[:len(selected)-1]
+		if err != nil || result != nil {
+			return result, err
+		}
+		// Skipping a candidate worth the same as the one just tried leads
+		// to the same selections.
+		next := i + 1
+		for next < len(candidates) && candidates[next].value == candidates[i].value {
+			next++
+		}
+		return visit(next, value)
+	}
+	return visit(0, 0)
+}
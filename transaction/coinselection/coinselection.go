@@ -0,0 +1,254 @@
+// Package coinselection picks, among a pool of UTXOs, the inputs funding a
+// transaction along with the fee its FeeModel asks for.
+//
+// A transaction to fund is given with its outputs, some of which may be
+// flagged as Change. Change outputs are funded with what is left once the
+// other outputs and the fee are paid, or removed when too little is left
+// for them, the rest then going to the miners.
+package coinselection
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+var (
+	// ErrInsufficientFunds is returned when the whole pool can not fund
+	// the transaction.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrNoExactMatch is returned by BranchAndBound when no set of UTXOs
+	// funds the transaction without change.
+	ErrNoExactMatch = errors.New("no exact match")
+)
+
+// Strategy selects the UTXOs funding a transaction.
+type Strategy interface {
+	// Select picks UTXOs of the pool funding the outputs of tx, on top of
+	// the inputs it may already have, and the fee computed by fees. The
+	// transaction is left untouched, see Result.Apply.
+	Select(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error)
+}
+
+// Result is the outcome of a Strategy.
+type Result struct {
+	// Inputs are the UTXOs selected.
+	Inputs []*transaction.UTXO
+	// Fee is the fee of the transaction funded by the inputs.
+	Fee uint64
+	// Change is the amount left for the change outputs, zero when they
+	// are to be removed.
+	Change uint64
+}
+
+// Apply adds the selected inputs to the transaction, and removes its change
+// outputs when no change is left for them. Transaction.Fee distributes the
+// change among the change outputs kept.
+func (r *Result) Apply(tx *transaction.Transaction) error {
+	if err := tx.AddInputsFromUTXOs(r.Inputs...); err != nil {
+		return err
+	}
+	if r.Change == 0 {
+		tx.Outputs = slices.DeleteFunc(tx.Outputs, func(o *transaction.TransactionOutput) bool {
+			return o.Change
+		})
+	}
+	return nil
+}
+
+// Selector adapts a Strategy to the transaction.InputSelector of
+// transaction.Builder.
+func Selector(s Strategy) transaction.InputSelector {
+	return selector{s}
+}
+
+type selector struct {
+	Strategy
+}
+
+func (s selector) SelectInputs(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) ([]*transaction.UTXO, error) {
+	result, err := s.Select(pool, tx, fees)
+	if err != nil {
+		return nil, err
+	}
+	return result.Inputs, nil
+}
+
+// funding evaluates how inputs fund a transaction.
+type funding struct {
+	tx *transaction.Transaction
+	// bare is the transaction without its change outputs.
+	bare   *transaction.Transaction
+	fees   transaction.FeeModel
+	target uint64
+	// base is the amount of the inputs the transaction already has.
+	base          uint64
+	changeOutputs int
+}
+
+func newFunding(tx *transaction.Transaction, fees transaction.FeeModel) (*funding, error) {
+	f := &funding{
+		tx:   tx,
+		bare: &transaction.Transaction{Version: tx.Version, LockTime: tx.LockTime, Inputs: tx.Inputs},
+		fees: fees,
+	}
+	for _, input := range tx.Inputs {
+		satoshis := input.SourceTxSatoshis()
+		if satoshis == nil {
+			return nil, transaction.ErrEmptyPreviousTx
+		}
+		f.base += *satoshis
+	}
+	for _, output := range tx.Outputs {
+		if output.Change {
+			f.changeOutputs++
+		} else {
+			f.target += output.Satoshis
+			f.bare.Outputs = append(f.bare.Outputs, output)
+		}
+	}
+	return f, nil
+}
+
+// fee returns the fee of the transaction spending the UTXOs, with or without
+// its change outputs.
+func (f *funding) fee(utxos []*transaction.UTXO, withChange bool) (uint64, error) {
+	if withChange {
+		return f.tx.FeeSpending(f.fees, utxos...)
+	}
+	return f.bare.FeeSpending(f.fees, utxos...)
+}
+
+// evaluate returns the result of funding the transaction with the UTXOs,
+// or nil along with the missing amount when they are not enough.
+func (f *funding) evaluate(utxos []*transaction.UTXO) (*Result, uint64, error) {
+	total := f.base
+	for _, utxo := range utxos {
+		total += utxo.Satoshis
+	}
+
+	if f.changeOutputs > 0 {
+		fee, err := f.fee(utxos, true)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Every change output needs at least a satoshi.
+		if total >= f.target+fee+uint64(f.changeOutputs) {
+			return &Result{Inputs: utxos, Fee: fee, Change: total - f.target - fee}, 0, nil
+		}
+	}
+
+	fee, err := f.fee(utxos, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total < f.target+fee {
+		return nil, f.target + fee - total, nil
+	}
+	// Without change outputs, the excess goes to the miners.
+	return &Result{Inputs: utxos, Fee: total - f.target}, 0, nil
+}
+
+// accumulate adds the UTXOs in order until they fund the transaction. As
+// BranchAndBound does, the fee of an input is estimated once from what it
+// adds to the fee of the transaction alone, and running totals tell when the
+// UTXOs added may be enough, the fee model then confirming the selection.
+func accumulate(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	f, err := newFunding(tx, fees)
+	if err != nil {
+		return nil, err
+	}
+	baseFee, err := f.fee(nil, false)
+	if err != nil {
+		return nil, err
+	}
+	total := f.base
+	// cost is the estimated fee of the UTXOs added.
+	var cost, missing uint64
+	for n := 0; n <= len(pool); n++ {
+		if n > 0 {
+			utxo := pool[n-1]
+			fee, err := f.fee([]*transaction.UTXO{utxo}, false)
+			if err != nil {
+				return nil, err
+			}
+			cost += fee - baseFee
+			total += utxo.Satoshis
+		}
+		if total < f.target+baseFee+cost && n < len(pool) {
+			continue
+		}
+		var result *Result
+		result, missing, err = f.evaluate(pool[:n:n])
+		if err != nil || result != nil {
+			return result, err
+		}
+		// The estimate fell short, carry on from the actual fee.
+		cost = missing + total - f.target - baseFee
+	}
+	return nil, fmt.Errorf("%w: %d satoshis missing", ErrInsufficientFunds, missing)
+}
+
+// LargestFirst selects the largest UTXOs first, resulting in few inputs.
+type LargestFirst struct{}
+
+func (LargestFirst) Select(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	sorted := slices.Clone(pool)
+	slices.SortStableFunc(sorted, func(a, b *transaction.UTXO) int {
+		return cmp.Compare(b.Satoshis, a.Satoshis)
+	})
+	return accumulate(sorted, tx, fees)
+}
+
+// OldestFirst selects the UTXOs confirmed first, consolidating old coins.
+type OldestFirst struct {
+	// Height returns the height of the block confirming the UTXO, false
+	// when it is not confirmed. Unconfirmed UTXOs are selected last.
+	Height func(utxo *transaction.UTXO) (uint32, bool)
+}
+
+func (o OldestFirst) Select(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	if o.Height == nil {
+		return nil, errors.New("OldestFirst needs the height of the UTXOs")
+	}
+	heights := make(map[*transaction.UTXO]uint64, len(pool))
+	for _, utxo := range pool {
+		height, ok := o.Height(utxo)
+		if ok {
+			heights[utxo] = uint64(height)
+		} else {
+			heights[utxo] = 1 << 32
+		}
+	}
+	sorted := slices.Clone(pool)
+	slices.SortStableFunc(sorted, func(a, b *transaction.UTXO) int {
+		return cmp.Compare(heights[a], heights[b])
+	})
+	return accumulate(sorted, tx, fees)
+}
+
+// Random selects UTXOs in random order, so that the inputs of a
+// transaction tell little about the wallet funding it.
+type Random struct {
+	// Rand is the randomness source, a source seeded from crypto/rand when
+	// nil.
+	Rand *rand.Rand
+}
+
+func (r Random) Select(pool []*transaction.UTXO, tx *transaction.Transaction, fees transaction.FeeModel) (*Result, error) {
+	shuffled := slices.Clone(pool)
+	if r.Rand != nil {
+		r.Rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+	} else {
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+	}
+	return accumulate(shuffled, tx, fees)
+}
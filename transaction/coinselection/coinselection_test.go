@@ -0,0 +1,277 @@
+package coinselection_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/coinselection"
+	"github.com/stretchr/testify/require"
+)
+
+// unlocker is an unlocking script template of a fixed length.
+type unlocker uint32
+
+func (u unlocker) Sign(*transaction.Transaction, uint32) (*script.Script, error) {
+	s := make(script.Script, u)
+	return &s, nil
+}
+
+func (u unlocker) EstimateLength(*transaction.Transaction, uint32) uint32 {
+	return uint32(u)
+}
+
+// byteFee charges a satoshi per byte of unlocking script, and 10 satoshis
+// per input and output, making fees easy to follow.
+type byteFee struct{}
+
+func (byteFee) ComputeFee(tx *transaction.Transaction) (uint64, error) {
+	fee := uint64(10 * (len(tx.Inputs) + len(tx.Outputs)))
+	for vin, input := range tx.Inputs {
+		fee += uint64(input.UnlockingScriptTemplate.EstimateLength(tx, uint32(vin)))
+	}
+	return fee, nil
+}
+
+// countingFee is byteFee counting the inputs of the transactions it
+// computes the fee of.
+type countingFee struct {
+	byteFee
+	inputs int
+}
+
+func (c *countingFee) ComputeFee(tx *transaction.Transaction) (uint64, error) {
+	c.inputs += len(tx.Inputs)
+	return c.byteFee.ComputeFee(tx)
+}
+
+func newUTXO(index uint32, satoshis uint64) *transaction.UTXO {
+	return &transaction.UTXO{
+		TxID:                    &chainhash.Hash{byte(index)},
+		Vout:                    index,
+		LockingScript:           &script.Script{script.OpTRUE},
+		Satoshis:                satoshis,
+		UnlockingScriptTemplate: unlocker(0),
+	}
+}
+
+func pool(amounts ...uint64) []*transaction.UTXO {
+	utxos := make([]*transaction.UTXO, len(amounts))
+	for i, amount := range amounts {
+		utxos[i] = newUTXO(uint32(i), amount)
+	}
+	return utxos
+}
+
+// payment returns a transaction paying satoshis, with a change output.
+func payment(satoshis uint64) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{
+		Satoshis:      satoshis,
+		LockingScript: &script.Script{script.OpTRUE},
+	})
+	tx.AddOutput(&transaction.TransactionOutput{
+		LockingScript: &script.Script{script.OpTRUE},
+		Change:        true,
+	})
+	return tx
+}
+
+func vouts(utxos []*transaction.UTXO) []uint32 {
+	indices := make([]uint32, len(utxos))
+	for i, utxo := range utxos {
+		indices[i] = utxo.Vout
+	}
+	return indices
+}
+
+func TestLargestFirst(t *testing.T) {
+	t.Run("selects the largest utxos", func(t *testing.T) {
+		result, err := coinselection.LargestFirst{}.Select(pool(100, 500, 300, 200), payment(600), byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{1, 2}, vouts(result.Inputs))
+		require.Equal(t, uint64(40), result.Fee)
+		require.Equal(t, uint64(800-600-40), result.Change)
+	})
+
+	t.Run("accounts for the unlocking script length", func(t *testing.T) {
+		utxos := pool(1000)
+		utxos[0].UnlockingScriptTemplate = unlocker(107)
+		result, err := coinselection.LargestFirst{}.Select(utxos, payment(600), byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(30+107), result.Fee)
+		require.Equal(t, uint64(1000-600-137), result.Change)
+	})
+
+	t.Run("drops the change when too little is left", func(t *testing.T) {
+		// 625 covers the payment and the fee of 20 without change, but not
+		// the fee of 30 with a change output along with a satoshi of change.
+		tx := payment(600)
+		result, err := coinselection.LargestFirst{}.Select(pool(625), tx, byteFee{})
+		require.NoError(t, err)
+		require.Zero(t, result.Change)
+		require.Equal(t, uint64(25), result.Fee)
+
+		require.NoError(t, result.Apply(tx))
+		require.Len(t, tx.Inputs, 1)
+		require.Len(t, tx.Outputs, 1)
+		require.False(t, tx.Outputs[0].Change)
+		require.Equal(t, result.Fee, tx.TotalInputSatoshis()-tx.TotalOutputSatoshis())
+	})
+
+	t.Run("reports the shortfall", func(t *testing.T) {
+		_, err := coinselection.LargestFirst{}.Select(pool(100, 200), payment(600), byteFee{})
+		require.ErrorIs(t, err, coinselection.ErrInsufficientFunds)
+		// Without change, 2 inputs and an output cost 30.
+		require.ErrorContains(t, err, "330 satoshis missing")
+	})
+
+	t.Run("counts the inputs of the transaction", func(t *testing.T) {
+		tx := payment(600)
+		require.NoError(t, tx.AddInputsFromUTXOs(newUTXO(9, 400)))
+		result, err := coinselection.LargestFirst{}.Select(pool(100, 300), tx, byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{1}, vouts(result.Inputs))
+		require.Equal(t, uint64(700-600-40), result.Change)
+	})
+
+	t.Run("does not recompute the fee of every selection", func(t *testing.T) {
+		amounts := make([]uint64, 200)
+		for i := range amounts {
+			amounts[i] = 100
+		}
+		fees := &countingFee{}
+		result, err := coinselection.LargestFirst{}.Select(pool(amounts...), payment(15000), fees)
+		require.NoError(t, err)
+		// Each input costs 10, 167 of them fund the payment, a fee of 1690
+		// and a satoshi of change.
+		require.Len(t, result.Inputs, 167)
+		require.Equal(t, uint64(1690), result.Fee)
+		require.Less(t, fees.inputs, 3*len(amounts))
+	})
+}
+
+func TestApply(t *testing.T) {
+	tx := payment(600)
+	result, err := coinselection.LargestFirst{}.Select(pool(500, 300), tx, byteFee{})
+	require.NoError(t, err)
+	require.NoError(t, result.Apply(tx))
+	require.NoError(t, tx.Fee(byteFee{}, transaction.ChangeDistributionEqual))
+
+	require.Len(t, tx.Inputs, 2)
+	require.Equal(t, result.Change, tx.Outputs[1].Satoshis)
+	require.Equal(t, result.Fee, tx.TotalInputSatoshis()-tx.TotalOutputSatoshis())
+}
+
+func TestOldestFirst(t *testing.T) {
+	utxos := pool(500, 300, 200, 400)
+	heights := map[uint32]uint32{0: 30, 1: 10, 3: 20}
+	strategy := coinselection.OldestFirst{
+		Height: func(utxo *transaction.UTXO) (uint32, bool) {
+			height, ok := heights[utxo.Vout]
+			return height, ok
+		},
+	}
+
+	result, err := strategy.Select(utxos, payment(600), byteFee{})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 3}, vouts(result.Inputs))
+
+	// The unconfirmed utxo comes last.
+	result, err = strategy.Select(utxos, payment(1200), byteFee{})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 3, 0, 2}, vouts(result.Inputs))
+
+	_, err = coinselection.OldestFirst{}.Select(utxos, payment(600), byteFee{})
+	require.Error(t, err)
+}
+
+func TestRandom(t *testing.T) {
+	utxos := pool(100, 200, 300, 400, 500, 600, 700, 800)
+
+	selected := make(map[uint32]bool)
+	strategy := coinselection.Random{Rand: rand.New(rand.NewPCG(1, 2))}
+	for i := 0; i < 20; i++ {
+		result, err := strategy.Select(utxos, payment(1000), byteFee{})
+		require.NoError(t, err)
+		var total uint64
+		for _, utxo := range result.Inputs {
+			total += utxo.Satoshis
+			selected[utxo.Vout] = true
+		}
+		require.Equal(t, total, 1000+result.Fee+result.Change)
+	}
+	require.Greater(t, len(selected), 4)
+
+	result, err := coinselection.Random{}.Select(utxos, payment(1000), byteFee{})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Inputs)
+}
+
+func TestBranchAndBound(t *testing.T) {
+	t.Run("finds an exact match", func(t *testing.T) {
+		// Paying 600 without change takes 630 from two inputs.
+		tx := payment(600)
+		result, err := coinselection.BranchAndBound{}.Select(pool(450, 350, 200, 280, 150), tx, byteFee{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []uint32{1, 3}, vouts(result.Inputs))
+		require.Zero(t, result.Change)
+		require.Equal(t, uint64(30), result.Fee)
+
+		require.NoError(t, result.Apply(tx))
+		require.Len(t, tx.Outputs, 1)
+	})
+
+	t.Run("tolerates the cost of change", func(t *testing.T) {
+		// Paying 600 from an input without change takes 620, the change
+		// output would cost 10 more plus a satoshi of change.
+		result, err := coinselection.BranchAndBound{}.Select(pool(1000, 631), payment(600), byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{1}, vouts(result.Inputs))
+		require.Equal(t, uint64(31), result.Fee)
+
+		_, err = coinselection.BranchAndBound{}.Select(pool(1000, 632), payment(600), byteFee{})
+		require.ErrorIs(t, err, coinselection.ErrNoExactMatch)
+
+		result, err = coinselection.BranchAndBound{CostOfChange: 50}.Select(pool(1000, 632), payment(600), byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{1}, vouts(result.Inputs))
+	})
+
+	t.Run("falls back without a match", func(t *testing.T) {
+		strategy := coinselection.BranchAndBound{Fallback: coinselection.LargestFirst{}}
+		result, err := strategy.Select(pool(1000, 900), payment(600), byteFee{})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{0}, vouts(result.Inputs))
+		require.Equal(t, uint64(1000-600-30), result.Change)
+	})
+
+	t.Run("reports the shortfall", func(t *testing.T) {
+		_, err := coinselection.BranchAndBound{}.Select(pool(100, 200), payment(600), byteFee{})
+		require.ErrorIs(t, err, coinselection.ErrInsufficientFunds)
+	})
+
+	t.Run("stops after MaxTries", func(t *testing.T) {
+		amounts := make([]uint64, 40)
+		for i := range amounts {
+			amounts[i] = 1000
+		}
+		// Every utxo is worth 990, no selection pays 5000 exactly.
+		_, err := coinselection.BranchAndBound{MaxTries: 1000}.Select(pool(amounts...), payment(5000), byteFee{})
+		require.ErrorIs(t, err, coinselection.ErrNoExactMatch)
+	})
+}
+
+func TestSelector(t *testing.T) {
+	builder := transaction.NewBuilder(byteFee{}, &script.Script{script.OpTRUE})
+	builder.PayTo(&script.Script{script.OpTRUE}, 600).AddUTXOs(pool(450, 350, 200, 280, 150)...)
+	builder.Selector = coinselection.Selector(coinselection.BranchAndBound{})
+
+	tx, err := builder.Build()
+	require.NoError(t, err)
+	require.Len(t, tx.Inputs, 2)
+	require.Len(t, tx.Outputs, 1)
+	require.Equal(t, uint64(630), tx.TotalInputSatoshis())
+}
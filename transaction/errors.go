@@ -60,6 +60,12 @@ var (
 	ErrInsufficientFunds = errors.New("insufficient funds provided")
 )
 
+// Sentinel errors reported by Builder.
+var (
+	ErrNoFeeModel      = errors.New("fee model not supplied")
+	ErrFeeNotConverged = errors.New("fee did not converge with the signed size")
+)
+
 // Sentinal errors reported by ordinal inscriptions.
 var (
 	ErrOutputsNotEmpty = errors.New("transaction outputs must be empty to avoid messing with Ordinal ordering scheme")
@@ -75,3 +81,19 @@ var (
 	ErrEmptyScripts          = errors.New("at least one of needed scripts is empty")
 	ErrInsufficientFees      = errors.New("fee paid not enough with new locking script")
 )
+
+// Sentinel errors reported by BEEF decoding, validation and merging.
+var (
+	ErrBeefVersion          = errors.New("invalid BEEF version")
+	ErrBeefTxFormat         = errors.New("invalid BEEF transaction format")
+	ErrBeefBumpIndex        = errors.New("BUMP index out of range")
+	ErrBeefTxOrder          = errors.New("transaction spends a transaction which comes after it")
+	ErrBeefDuplicateTx      = errors.New("duplicate transaction")
+	ErrBeefUnknownSource    = errors.New("unproven transaction spends an unknown transaction")
+	ErrBeefBumpMissingTx    = errors.New("BUMP does not prove the transaction")
+	ErrBeefBumpRootMismatch = errors.New("BUMPs at the same height have different roots")
+	ErrBeefTrailingBytes    = errors.New("trailing bytes after BEEF")
+	ErrBeefAtomicSubject    = errors.New("atomic BEEF subject not found")
+	ErrBeefLimitExceeded    = errors.New("BEEF exceeds the decoding limits")
+	ErrBeefMissingSourceTx  = errors.New("unproven transaction without its source transaction")
+)
@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrNoUnlockingScript = errors.New("inputs must have an unlocking script or an unlocker")
+	ErrInvalidRate       = errors.New("fee rate must be over a non-zero number of bytes")
+	ErrNoFeeRates        = errors.New("no fee rates fetched and no default set")
+	ErrNoMiningFee       = errors.New("arc policy without mining fee")
 )
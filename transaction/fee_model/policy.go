@@ -0,0 +1,177 @@
+package feemodel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultRefreshInterval is the RefreshInterval of a LivePolicy when
+	// zero.
+	DefaultRefreshInterval = 10 * time.Minute
+	// DefaultFetchTimeout is the FetchTimeout of a LivePolicy when zero.
+	DefaultFetchTimeout = 10 * time.Second
+)
+
+// PolicySource fetches the fee rates miners currently apply.
+type PolicySource interface {
+	FetchRates(ctx context.Context) (*SatoshisPerByte, error)
+}
+
+// PolicySourceFunc is a function used as a PolicySource.
+type PolicySourceFunc func(ctx context.Context) (*SatoshisPerByte, error)
+
+func (f PolicySourceFunc) FetchRates(ctx context.Context) (*SatoshisPerByte, error) {
+	return f(ctx)
+}
+
+// ArcPolicy fetches the mining fee of the policy of an ARC instance.
+type ArcPolicy struct {
+	Arc *broadcaster.Arc
+}
+
+func (a *ArcPolicy) FetchRates(ctx context.Context) (*SatoshisPerByte, error) {
+	response, err := a.Arc.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fee := response.Policy.MiningFee
+	if fee.Bytes == 0 {
+		return nil, ErrNoMiningFee
+	}
+	return &SatoshisPerByte{
+		Standard: Rate{Satoshis: fee.Satoshis, Bytes: fee.Bytes},
+	}, nil
+}
+
+// LivePolicy computes fees with the rates fetched from its Source, fetching
+// them again once they are RefreshInterval old. Until rates are fetched,
+// and while the Source fails, it uses the rates fetched last, or Default
+// when none were.
+//
+// Stale rates keep being used while they are fetched again in the
+// background, so that fees are computed without waiting for the Source
+// once rates were fetched.
+type LivePolicy struct {
+	Source  PolicySource
+	Default *SatoshisPerByte
+	// RefreshInterval is the time rates are used for,
+	// DefaultRefreshInterval when zero. Failures to fetch them are retried
+	// after the same time.
+	RefreshInterval time.Duration
+	// FetchTimeout bounds the time fetching the rates takes,
+	// DefaultFetchTimeout when zero.
+	FetchTimeout time.Duration
+	// OnError, when set, is called with the errors of the Source.
+	OnError func(err error)
+	// Now returns the current time, time.Now when nil.
+	Now func() time.Time
+
+	group   singleflight.Group
+	mu      sync.Mutex
+	rates   *SatoshisPerByte
+	fetched time.Time
+}
+
+// NewLivePolicy returns a LivePolicy fetching the rates from source every
+// refreshInterval, falling back to fallback.
+func NewLivePolicy(source PolicySource, refreshInterval time.Duration, fallback *SatoshisPerByte) *LivePolicy {
+	return &LivePolicy{
+		Source:          source,
+		Default:         fallback,
+		RefreshInterval: refreshInterval,
+	}
+}
+
+// NewArcPolicy returns a LivePolicy fetching the rates from the policy of
+// the ARC instance.
+func NewArcPolicy(arc *broadcaster.Arc, refreshInterval time.Duration, fallback *SatoshisPerByte) *LivePolicy {
+	return NewLivePolicy(&ArcPolicy{Arc: arc}, refreshInterval, fallback)
+}
+
+func (p *LivePolicy) ComputeFee(tx *transaction.Transaction) (uint64, error) {
+	rates := p.Rates(context.Background())
+	if rates == nil {
+		return 0, ErrNoFeeRates
+	}
+	return rates.ComputeFee(tx)
+}
+
+// Rates returns the rates fees are computed with. Rates due for a refresh
+// are fetched again in the background, and returned meanwhile. Until rates
+// were fetched once, it waits for the Source, or ctx to be done.
+func (p *LivePolicy) Rates(ctx context.Context) *SatoshisPerByte {
+	interval := p.RefreshInterval
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	p.mu.Lock()
+	rates, fetched := p.rates, p.fetched
+	p.mu.Unlock()
+
+	if fetched.IsZero() || p.now().Sub(fetched) >= interval {
+		// Errors are reported through OnError, the previous rates are
+		// kept.
+		ch := p.fetch(ctx)
+		if fetched.IsZero() {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+			}
+			p.mu.Lock()
+			rates = p.rates
+			p.mu.Unlock()
+		}
+	}
+	if rates != nil {
+		return rates
+	}
+	return p.Default
+}
+
+// Refresh fetches the rates from the Source right away.
+func (p *LivePolicy) Refresh(ctx context.Context) error {
+	select {
+	case result := <-p.fetch(ctx):
+		return result.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch fetches the rates, once for concurrent callers, updating them when
+// it succeeds. The fetch goes on when ctx is done, bounded by FetchTimeout.
+func (p *LivePolicy) fetch(ctx context.Context) <-chan singleflight.Result {
+	return p.group.DoChan("rates", func() (any, error) {
+		timeout := p.FetchTimeout
+		if timeout <= 0 {
+			timeout = DefaultFetchTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		rates, err := p.Source.FetchRates(ctx)
+		p.mu.Lock()
+		p.fetched = p.now()
+		if err == nil {
+			p.rates = rates
+		}
+		p.mu.Unlock()
+		if err != nil && p.OnError != nil {
+			p.OnError(err)
+		}
+		return rates, err
+	})
+}
+
+func (p *LivePolicy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
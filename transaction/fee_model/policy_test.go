@@ -0,0 +1,221 @@
+package feemodel_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/broadcaster"
+	feemodel "github.com/bitcoin-sv/go-sdk/transaction/fee_model"
+	"github.com/stretchr/testify/require"
+)
+
+// policyServer serves an ARC policy with the mining fee in satoshis per
+// 1000 bytes, failing while down is set.
+type policyServer struct {
+	*httptest.Server
+	satoshis atomic.Uint64
+	down     atomic.Bool
+	requests atomic.Int32
+}
+
+func newPolicyServer(t *testing.T, satoshis uint64) *policyServer {
+	s := &policyServer{}
+	s.satoshis.Store(satoshis)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/policy", r.URL.Path)
+		s.requests.Add(1)
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&broadcaster.ArcPolicyResponse{
+			Policy: broadcaster.ArcPolicy{
+				MiningFee: broadcaster.ArcFee{Satoshis: s.satoshis.Load(), Bytes: 1000},
+			},
+			Timestamp: time.Now(),
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *policyServer) arc() *broadcaster.Arc {
+	return &broadcaster.Arc{ApiUrl: s.URL + "/v1", Client: s.Client()}
+}
+
+// policyTx returns a signed transaction of 1000 bytes.
+func policyTx() *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	unlock := make(script.Script, 937)
+	tx.AddInput(&transaction.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: &unlock,
+	})
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: &script.Script{script.OpTRUE}})
+	return tx
+}
+
+func TestLivePolicy(t *testing.T) {
+	tx := policyTx()
+	require.Equal(t, 1000, tx.Size())
+	fallback := &feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 1, Bytes: 1}}
+
+	t.Run("computes fees with the arc policy", func(t *testing.T) {
+		server := newPolicyServer(t, 50)
+		policy := feemodel.NewArcPolicy(server.arc(), time.Minute, fallback)
+		var _ transaction.FeeModel = policy
+
+		for i := 0; i < 3; i++ {
+			fee, err := policy.ComputeFee(tx)
+			require.NoError(t, err)
+			require.Equal(t, uint64(50), fee)
+		}
+		require.Equal(t, int32(1), server.requests.Load())
+	})
+
+	t.Run("refreshes the rates", func(t *testing.T) {
+		server := newPolicyServer(t, 50)
+		policy := feemodel.NewArcPolicy(server.arc(), time.Minute, fallback)
+		var now atomic.Int64
+		now.Store(time.Now().UnixNano())
+		policy.Now = func() time.Time { return time.Unix(0, now.Load()) }
+
+		fee, err := policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), fee)
+
+		server.satoshis.Store(100)
+		now.Add(int64(30 * time.Second))
+		fee, err = policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), fee)
+		require.Equal(t, int32(1), server.requests.Load())
+
+		// The stale rates are used until the new ones are fetched.
+		now.Add(int64(30 * time.Second))
+		fee, err = policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), fee)
+		require.Eventually(t, func() bool {
+			fee, err := policy.ComputeFee(tx)
+			return err == nil && fee == 100
+		}, time.Second, time.Millisecond)
+		require.Equal(t, int32(2), server.requests.Load())
+	})
+
+	t.Run("falls back to the default", func(t *testing.T) {
+		server := newPolicyServer(t, 50)
+		server.down.Store(true)
+		var errs atomic.Int32
+		policy := feemodel.NewArcPolicy(server.arc(), time.Minute, fallback)
+		policy.OnError = func(err error) { errs.Add(1) }
+
+		fee, err := policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1000), fee)
+		require.Equal(t, int32(1), errs.Load())
+
+		// The default has no rates either.
+		policy = feemodel.NewArcPolicy(server.arc(), time.Minute, nil)
+		_, err = policy.ComputeFee(tx)
+		require.ErrorIs(t, err, feemodel.ErrNoFeeRates)
+	})
+
+	t.Run("keeps the rates while the source fails", func(t *testing.T) {
+		server := newPolicyServer(t, 50)
+		policy := feemodel.NewArcPolicy(server.arc(), time.Minute, fallback)
+		var now atomic.Int64
+		now.Store(time.Now().UnixNano())
+		policy.Now = func() time.Time { return time.Unix(0, now.Load()) }
+		var errs atomic.Int32
+		policy.OnError = func(err error) { errs.Add(1) }
+		require.NoError(t, policy.Refresh(context.Background()))
+
+		server.down.Store(true)
+		now.Add(int64(time.Minute))
+		fee, err := policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), fee)
+		require.Eventually(t, func() bool {
+			return errs.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		// Failures are retried after the refresh interval.
+		fee, err = policy.ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), fee)
+		require.Equal(t, int32(2), server.requests.Load())
+		require.Error(t, policy.Refresh(context.Background()))
+	})
+
+	t.Run("does not wait for the source once rates are fetched", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		var calls atomic.Int32
+		source := feemodel.PolicySourceFunc(func(ctx context.Context) (*feemodel.SatoshisPerByte, error) {
+			if calls.Add(1) > 1 {
+				started <- struct{}{}
+				<-release
+			}
+			return &feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 50, Bytes: 1000}}, nil
+		})
+		policy := feemodel.NewLivePolicy(source, time.Minute, nil)
+		var now atomic.Int64
+		now.Store(time.Now().UnixNano())
+		policy.Now = func() time.Time { return time.Unix(0, now.Load()) }
+		require.NoError(t, policy.Refresh(context.Background()))
+		defer close(release)
+
+		// The refresh hangs, concurrent fees use the stale rates.
+		now.Add(int64(time.Minute))
+		fees := make(chan uint64)
+		for i := 0; i < 10; i++ {
+			go func() {
+				fee, _ := policy.ComputeFee(tx)
+				fees <- fee
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			select {
+			case fee := <-fees:
+				require.Equal(t, uint64(50), fee)
+			case <-time.After(time.Second):
+				t.Fatal("fee computation waited for the source")
+			}
+		}
+		// A single refresh is started in the background, none other
+		// starting while it hangs.
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("rates were not refreshed")
+		}
+		require.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("requires a mining fee in the arc policy", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(&broadcaster.ArcPolicyResponse{Timestamp: time.Now()})
+		}))
+		t.Cleanup(server.Close)
+		arc := &broadcaster.Arc{ApiUrl: server.URL + "/v1", Client: server.Client()}
+		policy := feemodel.NewArcPolicy(arc, time.Minute, nil)
+		require.ErrorIs(t, policy.Refresh(context.Background()), feemodel.ErrNoMiningFee)
+	})
+
+	t.Run("uses any policy source", func(t *testing.T) {
+		source := feemodel.PolicySourceFunc(func(context.Context) (*feemodel.SatoshisPerByte, error) {
+			return &feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 1, Bytes: 100}}, nil
+		})
+		fee, err := feemodel.NewLivePolicy(source, 0, nil).ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), fee)
+	})
+}
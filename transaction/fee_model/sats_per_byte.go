@@ -0,0 +1,107 @@
+package feemodel
+
+import (
+	"math/big"
+
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+// Rate is a fee rate of Satoshis per Bytes, such as 1 satoshi per 20 bytes
+// for 0.05 sat/byte.
+type Rate struct {
+	Satoshis uint64
+	Bytes    uint64
+}
+
+// SatoshisPerByte charges the bytes of data outputs and inscriptions at the
+// Data rate, and the other bytes at the Standard rate, the Data rate
+// defaulting to the Standard one. The fee is computed exactly, rounding up
+// only the total to a whole satoshi, and is at least MinFee.
+type SatoshisPerByte struct {
+	Standard Rate
+	Data     Rate
+	MinFee   uint64
+}
+
+func (s *SatoshisPerByte) ComputeFee(tx *transaction.Transaction) (uint64, error) {
+	standard, data := s.Standard, s.Data
+	if data == (Rate{}) {
+		data = standard
+	}
+	if standard.Bytes == 0 || data.Bytes == 0 {
+		return 0, ErrInvalidRate
+	}
+
+	size, err := estimateSize(tx)
+	if err != nil {
+		return 0, err
+	}
+	dataBytes := 0
+	for _, o := range tx.Outputs {
+		dataBytes += dataLength(o.LockingScript)
+	}
+	standardBytes := size - dataBytes
+
+	// standardBytes * standard + dataBytes * data, over a common
+	// denominator.
+	fee := new(big.Int).Mul(big.NewInt(int64(standardBytes)), new(big.Int).SetUint64(standard.Satoshis))
+	fee.Mul(fee, new(big.Int).SetUint64(data.Bytes))
+	dataFee := new(big.Int).Mul(big.NewInt(int64(dataBytes)), new(big.Int).SetUint64(data.Satoshis))
+	dataFee.Mul(dataFee, new(big.Int).SetUint64(standard.Bytes))
+	fee.Add(fee, dataFee)
+	denominator := new(big.Int).Mul(new(big.Int).SetUint64(standard.Bytes), new(big.Int).SetUint64(data.Bytes))
+	// Rounds up.
+	fee.Add(fee, denominator).Sub(fee, big.NewInt(1)).Quo(fee, denominator)
+
+	if !fee.IsUint64() {
+		return 0, ErrInvalidRate
+	}
+	return max(fee.Uint64(), s.MinFee), nil
+}
+
+// dataLength returns the number of bytes of the locking script holding
+// data: all of them for a data output, those of the inscription envelopes
+// otherwise.
+func dataLength(s *script.Script) int {
+	if s.IsData() {
+		return len(*s)
+	}
+	chunks, err := script.DecodeScript(*s)
+	if err != nil {
+		return 0
+	}
+
+	length := 0
+	start := -1
+	offset := 0
+	for i, chunk := range chunks {
+		// OP_FALSE OP_IF "ord" ... OP_ENDIF
+		if start < 0 && chunk.Op == script.OpFALSE && i+2 < len(chunks) &&
+			chunks[i+1].Op == script.OpIF && string(chunks[i+2].Data) == transaction.OrdinalsPrefix {
+			start = offset
+		}
+		offset += chunkLength(chunk)
+		if start >= 0 && chunk.Op == script.OpENDIF {
+			length += offset - start
+			start = -1
+		}
+	}
+	return length
+}
+
+// chunkLength returns the number of bytes encoding the chunk.
+func chunkLength(chunk *script.ScriptChunk) int {
+	switch {
+	case chunk.Op == script.OpPUSHDATA1:
+		return 2 + len(chunk.Data)
+	case chunk.Op == script.OpPUSHDATA2:
+		return 3 + len(chunk.Data)
+	case chunk.Op == script.OpPUSHDATA4:
+		return 5 + len(chunk.Data)
+	case chunk.Op >= script.OpDATA1 && chunk.Op <= script.OpDATA75:
+		return 1 + len(chunk.Data)
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,98 @@
+package feemodel_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	feemodel "github.com/bitcoin-sv/go-sdk/transaction/fee_model"
+	"github.com/stretchr/testify/require"
+)
+
+// signedTx returns a signed transaction of an input with an unlocking
+// script of unlockLength bytes, and the outputs.
+func signedTx(unlockLength int, outputs ...*script.Script) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	unlock := script.Script(bytes.Repeat([]byte{script.OpTRUE}, unlockLength))
+	tx.AddInput(&transaction.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: &unlock,
+	})
+	for _, lock := range outputs {
+		tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: lock})
+	}
+	return tx
+}
+
+func TestSatoshisPerByte(t *testing.T) {
+	lock := &script.Script{script.OpTRUE}
+
+	t.Run("charges fractions of kilobytes", func(t *testing.T) {
+		tx := signedTx(938, lock)
+		require.Equal(t, 1001, tx.Size())
+
+		fee, err := (&feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 1, Bytes: 20}}).ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(51), fee)
+
+		kbFee, err := (&feemodel.SatoshisPerKilobyte{Satoshis: 50}).ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(100), kbFee)
+	})
+
+	t.Run("charges data outputs at the data rate", func(t *testing.T) {
+		data := &script.Script{}
+		require.NoError(t, data.AppendOpcodes(script.OpFALSE, script.OpRETURN))
+		require.NoError(t, data.AppendPushData(make([]byte, 500)))
+		tx := signedTx(100, lock, data)
+
+		rates := &feemodel.SatoshisPerByte{
+			Standard: feemodel.Rate{Satoshis: 1, Bytes: 1},
+			Data:     feemodel.Rate{Satoshis: 1, Bytes: 3},
+		}
+		fee, err := rates.ComputeFee(tx)
+		require.NoError(t, err)
+		// The 505 bytes of the data script cost 168 and a third, the
+		// others one each.
+		require.Equal(t, uint64(tx.Size()-505+169), fee)
+
+		// The data rate defaults to the standard one.
+		fee, err = (&feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 1, Bytes: 1}}).ComputeFee(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(tx.Size()), fee)
+	})
+
+	t.Run("charges inscriptions at the data rate", func(t *testing.T) {
+		tx := signedTx(100)
+		require.NoError(t, tx.Inscribe(&script.InscriptionArgs{
+			LockingScript: lock,
+			Data:          make([]byte, 300),
+			ContentType:   "text/plain",
+		}))
+
+		rates := &feemodel.SatoshisPerByte{
+			Standard: feemodel.Rate{Satoshis: 1, Bytes: 1},
+			Data:     feemodel.Rate{Satoshis: 0, Bytes: 1},
+		}
+		fee, err := rates.ComputeFee(tx)
+		require.NoError(t, err)
+		// Only the bytes outside the envelope are charged: the OP_TRUE of
+		// the locking script, its length and amount, and the rest of the
+		// transaction.
+		require.Equal(t, uint64(tx.Size()-len(*tx.Outputs[0].LockingScript)+1), fee)
+	})
+
+	t.Run("charges at least the minimum fee", func(t *testing.T) {
+		rates := &feemodel.SatoshisPerByte{Standard: feemodel.Rate{Satoshis: 1, Bytes: 1000}, MinFee: 10}
+		fee, err := rates.ComputeFee(signedTx(100, lock))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), fee)
+	})
+
+	t.Run("rejects rates without bytes", func(t *testing.T) {
+		_, err := (&feemodel.SatoshisPerByte{}).ComputeFee(signedTx(100, lock))
+		require.ErrorIs(t, err, feemodel.ErrInvalidRate)
+	})
+}
@@ -0,0 +1,32 @@
+package feemodel
+
+import (
+	"github.com/bitcoin-sv/go-sdk/transaction"
+)
+
+// estimateSize returns the size of the transaction once signed, using the
+// estimated length of the unlocking scripts not signed yet.
+func estimateSize(tx *transaction.Transaction) (int, error) {
+	size := 4
+	size += transaction.VarInt(len(tx.Inputs)).Length()
+	for vin, i := range tx.Inputs {
+		size += 40
+		if i.UnlockingScript != nil && len(*i.UnlockingScript) > 0 {
+			scriptLen := len(*i.UnlockingScript)
+			size += transaction.VarInt(scriptLen).Length() + scriptLen
+		} else if i.UnlockingScriptTemplate != nil {
+			scriptLen := int(i.UnlockingScriptTemplate.EstimateLength(tx, uint32(vin)))
+			size += transaction.VarInt(scriptLen).Length() + scriptLen
+		} else {
+			return 0, ErrNoUnlockingScript
+		}
+	}
+	size += transaction.VarInt(len(tx.Outputs)).Length()
+	for _, o := range tx.Outputs {
+		size += 8
+		size += transaction.VarInt(len(*o.LockingScript)).Length()
+		size += len(*o.LockingScript)
+	}
+	size += 4
+	return size, nil
+}
@@ -1,9 +1,9 @@
 package transaction
 
 import (
+	crand "crypto/rand"
+	"math/rand/v2"
 	"slices"
-
-	"github.com/pkg/errors"
 )
 
 type ChangeDistribution int
@@ -17,7 +17,51 @@ type FeeModel interface {
 	ComputeFee(tx *Transaction) (uint64, error)
 }
 
-func (tx *Transaction) Fee(f FeeModel, changeDistribution ChangeDistribution) error {
+// DefaultMinChange is the smallest change output kept by
+// ChangeDistributionRandom, the dust limit of the network.
+const DefaultMinChange uint64 = 1
+
+// FeeOption configures how Fee distributes the change.
+type FeeOption func(*feeOptions)
+
+type feeOptions struct {
+	rand      *rand.Rand
+	minChange uint64
+}
+
+// WithChangeRand sets the randomness source of ChangeDistributionRandom,
+// so that the distribution can be reproduced. A source seeded from
+// crypto/rand is used by default.
+func WithChangeRand(r *rand.Rand) FeeOption {
+	return func(o *feeOptions) {
+		o.rand = r
+	}
+}
+
+// WithMinChange sets the smallest amount of a change output with
+// ChangeDistributionRandom, DefaultMinChange by default.
+func WithMinChange(satoshis uint64) FeeOption {
+	return func(o *feeOptions) {
+		o.minChange = satoshis
+	}
+}
+
+// Fee computes the fee of the transaction with the fee model and
+// distributes what is left of the inputs among its change outputs. Change
+// outputs are removed when there is not enough left for all of them.
+//
+// ChangeDistributionEqual gives every change output the same amount.
+// ChangeDistributionRandom splits the change into random, non-round
+// amounts no smaller than the minimum change, so that change outputs can
+// not be told apart from payments by their amount. Change outputs which
+// would fall below the minimum are removed, their share going to the other
+// change outputs.
+func (tx *Transaction) Fee(f FeeModel, changeDistribution ChangeDistribution, opts ...FeeOption) error {
+	options := &feeOptions{minChange: DefaultMinChange}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	fee, err := f.ComputeFee(tx)
 	if err != nil {
 		return err
@@ -43,6 +87,9 @@ func (tx *Transaction) Fee(f FeeModel, changeDistribution ChangeDistribution) er
 		return ErrInsufficientInputs
 	}
 	change := satsIn - satsOut - fee
+	if changeOuts == 0 {
+		return nil
+	}
 	// There is not enough change to distribute among the change outputs.
 	// We'll remove all change outputs and leave the extra for the miners.
 	if changeOuts > change {
@@ -52,7 +99,7 @@ func (tx *Transaction) Fee(f FeeModel, changeDistribution ChangeDistribution) er
 	} else {
 		switch changeDistribution {
 		case ChangeDistributionRandom:
-			return errors.New("not-implemented")
+			tx.distributeRandomChange(change, options)
 		case ChangeDistributionEqual:
 			changePerOutput := change / changeOuts
 			for _, o := range tx.Outputs {
@@ -64,3 +111,87 @@ func (tx *Transaction) Fee(f FeeModel, changeDistribution ChangeDistribution) er
 	}
 	return nil
 }
+
+// FeeSpending returns the fee f computes for the transaction once it spends
+// the UTXOs on top of its inputs. The transaction is left untouched, so that
+// input selectors can tell whether UTXOs fund it before adding them.
+func (tx *Transaction) FeeSpending(f FeeModel, utxos ...*UTXO) (uint64, error) {
+	trial := &Transaction{
+		Version:  tx.Version,
+		LockTime: tx.LockTime,
+		Inputs:   slices.Clone(tx.Inputs),
+		Outputs:  tx.Outputs,
+	}
+	if err := trial.AddInputsFromUTXOs(utxos...); err != nil {
+		return 0, err
+	}
+	return f.ComputeFee(trial)
+}
+
+// distributeRandomChange splits change among as many change outputs as the
+// minimum change allows, removing the others. Every output gets the
+// minimum, and the rest is split at uniformly random points.
+func (tx *Transaction) distributeRandomChange(change uint64, options *feeOptions) {
+	minChange := max(options.minChange, 1)
+	r := options.rand
+	if r == nil {
+		var seed [32]byte
+		_, _ = crand.Read(seed[:])
+		r = rand.New(rand.NewChaCha8(seed))
+	}
+
+	var changeOutputs []*TransactionOutput
+	for _, o := range tx.Outputs {
+		if o.Change {
+			changeOutputs = append(changeOutputs, o)
+		}
+	}
+	keep := min(uint64(len(changeOutputs)), change/minChange)
+	if keep < uint64(len(changeOutputs)) {
+		dropped := changeOutputs[keep:]
+		changeOutputs = changeOutputs[:keep]
+		tx.Outputs = slices.DeleteFunc(tx.Outputs, func(o *TransactionOutput) bool {
+			return slices.Contains(dropped, o)
+		})
+	}
+	if keep == 0 {
+		return
+	}
+
+	rest := change - keep*minChange
+	cuts := make([]uint64, 0, keep+1)
+	cuts = append(cuts, 0, rest)
+	for i := uint64(1); i < keep; i++ {
+		cuts = append(cuts, r.Uint64N(rest+1))
+	}
+	slices.Sort(cuts)
+	amounts := make([]uint64, keep)
+	for i := range amounts {
+		amounts[i] = minChange + cuts[i+1] - cuts[i]
+	}
+
+	// Round amounts stand out as change, move a satoshi between them and
+	// another output, picked so that it does not become round instead.
+	for i := range amounts {
+		if amounts[i]%100 != 0 {
+			continue
+		}
+		for k := 1; k < len(amounts); k++ {
+			j := (i + k) % len(amounts)
+			if amounts[i] > minChange && amounts[j]%100 != 99 {
+				amounts[i]--
+				amounts[j]++
+				break
+			}
+			if amounts[j] > minChange && amounts[j]%100 != 1 {
+				amounts[j]--
+				amounts[i]++
+				break
+			}
+		}
+	}
+
+	for i, o := range changeOutputs {
+		o.Satoshis = amounts[i]
+	}
+}
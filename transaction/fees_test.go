@@ -0,0 +1,131 @@
+package transaction_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	feemodel "github.com/bitcoin-sv/go-sdk/transaction/fee_model"
+	"github.com/stretchr/testify/require"
+)
+
+// changeTx spends satoshis, paying 1000 of them and leaving the rest to
+// the given number of change outputs.
+func changeTx(satoshis uint64, changeOutputs int) *transaction.Transaction {
+	lock := &script.Script{script.OpTRUE}
+	tx := transaction.NewTransaction()
+	input := &transaction.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: &script.Script{script.OpTRUE},
+	}
+	input.SetSourceTxOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: lock})
+	tx.AddInput(input)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: lock})
+	for i := 0; i < changeOutputs; i++ {
+		tx.AddOutput(&transaction.TransactionOutput{Change: true, LockingScript: lock})
+	}
+	return tx
+}
+
+func changeAmounts(tx *transaction.Transaction) (amounts []uint64, total uint64) {
+	for _, o := range tx.Outputs {
+		if o.Change {
+			amounts = append(amounts, o.Satoshis)
+			total += o.Satoshis
+		}
+	}
+	return amounts, total
+}
+
+func TestFeeRandomChange(t *testing.T) {
+	fee := &feemodel.SatoshisPerKilobyte{Satoshis: 10}
+
+	t.Run("splits the change into random amounts", func(t *testing.T) {
+		tx := changeTx(101000, 4)
+		err := tx.Fee(fee, transaction.ChangeDistributionRandom, transaction.WithChangeRand(rand.New(rand.NewPCG(1, 2))))
+		require.NoError(t, err)
+
+		amounts, total := changeAmounts(tx)
+		require.Len(t, amounts, 4)
+		require.Equal(t, uint64(101000-1000-10), total)
+		for i, amount := range amounts {
+			require.NotZero(t, amount%100, "round amount %d", amount)
+			for _, other := range amounts[:i] {
+				require.NotEqual(t, other, amount)
+			}
+		}
+	})
+
+	t.Run("is reproducible", func(t *testing.T) {
+		distribute := func(seed uint64) []uint64 {
+			tx := changeTx(101000, 3)
+			err := tx.Fee(fee, transaction.ChangeDistributionRandom, transaction.WithChangeRand(rand.New(rand.NewPCG(seed, seed))))
+			require.NoError(t, err)
+			amounts, _ := changeAmounts(tx)
+			return amounts
+		}
+		require.Equal(t, distribute(7), distribute(7))
+		require.NotEqual(t, distribute(7), distribute(8))
+	})
+
+	t.Run("keeps amounts above the minimum", func(t *testing.T) {
+		for seed := uint64(0); seed < 50; seed++ {
+			tx := changeTx(4010, 3)
+			err := tx.Fee(fee, transaction.ChangeDistributionRandom,
+				transaction.WithChangeRand(rand.New(rand.NewPCG(seed, 0))),
+				transaction.WithMinChange(500))
+			require.NoError(t, err)
+
+			amounts, total := changeAmounts(tx)
+			require.Len(t, amounts, 3)
+			require.Equal(t, uint64(3000), total)
+			for _, amount := range amounts {
+				require.GreaterOrEqual(t, amount, uint64(500))
+			}
+		}
+	})
+
+	t.Run("never leaves round amounts", func(t *testing.T) {
+		for seed := uint64(0); seed < 2000; seed++ {
+			r := rand.New(rand.NewPCG(seed, 1))
+			changeOutputs := 2 + r.IntN(5)
+			// Totals of round hundreds, with a minimum of a round hundred or
+			// of some satoshis.
+			satoshis := 2010 + 100*r.Uint64N(200)
+			minChange := []uint64{1, 100, 546}[seed%3]
+			tx := changeTx(satoshis, changeOutputs)
+			err := tx.Fee(fee, transaction.ChangeDistributionRandom,
+				transaction.WithChangeRand(r),
+				transaction.WithMinChange(minChange))
+			require.NoError(t, err)
+
+			amounts, total := changeAmounts(tx)
+			require.Equal(t, satoshis-1010, total)
+			if len(amounts) < 2 {
+				continue
+			}
+			for _, amount := range amounts {
+				require.NotZero(t, amount%100, "seed %d: round amount in %v", seed, amounts)
+				require.GreaterOrEqual(t, amount, minChange)
+			}
+		}
+	})
+
+	t.Run("drops change outputs below the minimum", func(t *testing.T) {
+		tx := changeTx(2510, 3)
+		err := tx.Fee(fee, transaction.ChangeDistributionRandom, transaction.WithMinChange(600))
+		require.NoError(t, err)
+
+		amounts, total := changeAmounts(tx)
+		require.Len(t, amounts, 2)
+		require.Equal(t, uint64(1500), total)
+		require.Len(t, tx.Outputs, 3)
+
+		tx = changeTx(1500, 2)
+		err = tx.Fee(fee, transaction.ChangeDistributionRandom, transaction.WithMinChange(600))
+		require.NoError(t, err)
+		require.Len(t, tx.Outputs, 1)
+	})
+}
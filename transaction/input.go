@@ -1,13 +1,13 @@
 package transaction
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
 
 	"github.com/bitcoin-sv/go-sdk/chainhash"
 	script "github.com/bitcoin-sv/go-sdk/script"
-	"github.com/bitcoin-sv/go-sdk/util"
 	"github.com/pkg/errors"
 )
 
@@ -98,8 +98,7 @@ func (i *TransactionInput) readFrom(r io.Reader, extended bool) (int64, error) {
 		return bytesRead, err
 	}
 
-	scriptBytes := make([]byte, l)
-	n, err = io.ReadFull(r, scriptBytes)
+	scriptBytes, n, err := readBytes(r, l)
 	bytesRead += int64(n)
 	if err != nil {
 		return bytesRead, errors.Wrapf(err, "script(%d): got %d bytes", l, n)
@@ -135,8 +134,7 @@ func (i *TransactionInput) readFrom(r io.Reader, extended bool) (int64, error) {
 			return bytesRead, err
 		}
 
-		scriptBytes := make([]byte, scriptLen)
-		n, err := io.ReadFull(r, scriptBytes)
+		scriptBytes, n, err := readBytes(r, scriptLen)
 		bytesRead += int64(n)
 		if err != nil {
 			return bytesRead, errors.Wrapf(err, "script(%d): got %d bytes", scriptLen.Length(), n)
@@ -171,22 +169,32 @@ sequence:     %x
 
 // Bytes encodes the Input into a hex byte array.
 func (i *TransactionInput) Bytes(clear bool) []byte {
-	h := make([]byte, 0)
+	buf := new(bytes.Buffer)
+	i.writeTo(&countingWriter{w: buf}, clear)
+	return buf.Bytes()
+}
+
+// WriteTo writes the encoded Input to the `io.Writer`.
+func (i *TransactionInput) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	i.writeTo(cw, false)
+	return cw.n, cw.err
+}
 
-	h = append(h, i.SourceTXID.CloneBytes()...)
-	h = append(h, util.LittleEndianBytes(i.SourceTxOutIndex, 4)...)
+func (i *TransactionInput) writeTo(cw *countingWriter, clear bool) {
+	cw.write(i.SourceTXID.CloneBytes())
+	cw.writeUint32(i.SourceTxOutIndex)
 	if clear {
-		h = append(h, 0x00)
+		cw.writeByte(0x00)
 	} else {
 		if i.UnlockingScript == nil {
-			h = append(h, VarInt(0).Bytes()...)
+			cw.writeVarInt(0)
 		} else {
-			h = append(h, VarInt(uint64(len(*i.UnlockingScript))).Bytes()...)
-			h = append(h, *i.UnlockingScript...)
+			cw.writeVarInt(VarInt(len(*i.UnlockingScript)))
+			cw.write(*i.UnlockingScript)
 		}
 	}
-
-	return append(h, util.LittleEndianBytes(i.SequenceNumber, 4)...)
+	cw.writeUint32(i.SequenceNumber)
 }
 
 func (i *TransactionInput) SetSourceTxOutput(txo *TransactionOutput) {
@@ -2,6 +2,8 @@ package transaction
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -40,7 +42,7 @@ func (ip IndexedPath) GetOffsetLeaf(layer int, offset uint64) *PathElement {
 	prevOffset := offset * 2
 	left := ip.GetOffsetLeaf(layer-1, prevOffset)
 	right := ip.GetOffsetLeaf(layer-1, prevOffset+1)
-	if left != nil && right != nil {
+	if left != nil && right != nil && left.Hash != nil && (right.Hash != nil || right.Duplicate != nil && *right.Duplicate) {
 		var digest []byte
 		if right.Duplicate != nil && *right.Duplicate {
 			digest = append(left.Hash.CloneBytes(), left.Hash.CloneBytes()...)
@@ -100,6 +102,10 @@ func NewMerklePathFromReader(reader io.Reader) (*MerklePath, error) {
 		return nil, err
 	}
 
+	if treeHeight == 0 {
+		return nil, errors.New("BUMP tree height must be at least 1")
+	}
+
 	// We expect tree height levels.
 	bump.Path = make([][]*PathElement, treeHeight)
 
@@ -113,7 +119,8 @@ func NewMerklePathFromReader(reader io.Reader) (*MerklePath, error) {
 		if nLeavesAtThisHeight == 0 {
 			return nil, errors.New("There are no leaves at height: " + fmt.Sprint(lv) + " which makes this invalid")
 		}
-		bump.Path[lv] = make([]*PathElement, nLeavesAtThisHeight)
+		// The number of leaves is untrusted, so the level grows as leaves are read.
+		bump.Path[lv] = make([]*PathElement, 0, min(uint64(nLeavesAtThisHeight), 1024))
 		for lf := uint64(0); lf < uint64(nLeavesAtThisHeight); lf++ {
 			// For each leaf we parse the offset, hash, txid and duplicate.
 			var offset VarInt
@@ -137,7 +144,7 @@ func NewMerklePathFromReader(reader io.Reader) (*MerklePath, error) {
 				l.Duplicate = &dup
 			} else {
 				hash := make([]byte, 32)
-				if _, err = reader.Read(hash); err != nil {
+				if _, err = io.ReadFull(reader, hash); err != nil {
 					return nil, err
 				} else if l.Hash, err = chainhash.NewHash(hash); err != nil {
 					return nil, err
@@ -146,7 +153,7 @@ func NewMerklePathFromReader(reader io.Reader) (*MerklePath, error) {
 			if txid {
 				l.Txid = &txid
 			}
-			bump.Path[lv][lf] = &l
+			bump.Path[lv] = append(bump.Path[lv], &l)
 		}
 	}
 
@@ -162,14 +169,26 @@ func NewMerklePathFromReader(reader io.Reader) (*MerklePath, error) {
 
 // Bytes encodes a BUMP as a slice of bytes. BUMP Binary Format according to BRC-74 https://brc.dev/74
 func (mp *MerklePath) Bytes() []byte {
-	bytes := VarInt(mp.BlockHeight).Bytes()
+	buf := new(bytes.Buffer)
+	mp.writeTo(&countingWriter{w: buf})
+	return buf.Bytes()
+}
+
+// WriteTo writes the BUMP to the `io.Writer` in the same format as Bytes.
+func (mp *MerklePath) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	mp.writeTo(cw)
+	return cw.n, cw.err
+}
+
+func (mp *MerklePath) writeTo(cw *countingWriter) {
+	cw.writeVarInt(VarInt(mp.BlockHeight))
 	treeHeight := len(mp.Path)
-	bytes = append(bytes, byte(treeHeight))
+	cw.writeByte(byte(treeHeight))
 	for level := 0; level < treeHeight; level++ {
-		nLeaves := len(mp.Path[level])
-		bytes = append(bytes, VarInt(nLeaves).Bytes()...)
+		cw.writeVarInt(VarInt(len(mp.Path[level])))
 		for _, leaf := range mp.Path[level] {
-			bytes = append(bytes, VarInt(leaf.Offset).Bytes()...)
+			cw.writeVarInt(VarInt(leaf.Offset))
 			flags := byte(0)
 			if leaf.Duplicate != nil && *leaf.Duplicate {
 				flags |= 1
@@ -177,13 +196,39 @@ func (mp *MerklePath) Bytes() []byte {
 			if leaf.Txid != nil && *leaf.Txid {
 				flags |= 2
 			}
-			bytes = append(bytes, flags)
+			cw.writeByte(flags)
 			if (flags & 1) == 0 {
-				bytes = append(bytes, leaf.Hash.CloneBytes()...)
+				cw.write(leaf.Hash.CloneBytes())
 			}
 		}
 	}
-	return bytes
+}
+
+// Clone returns a deep copy of the MerklePath.
+func (mp *MerklePath) Clone() *MerklePath {
+	clone := &MerklePath{
+		BlockHeight: mp.BlockHeight,
+		Path:        make([][]*PathElement, len(mp.Path)),
+	}
+	for h, level := range mp.Path {
+		clone.Path[h] = make([]*PathElement, len(level))
+		for i, leaf := range level {
+			l := &PathElement{Offset: leaf.Offset}
+			if leaf.Hash != nil {
+				l.Hash = (*chainhash.Hash)(leaf.Hash.CloneBytes())
+			}
+			if leaf.Txid != nil {
+				txid := *leaf.Txid
+				l.Txid = &txid
+			}
+			if leaf.Duplicate != nil {
+				dup := *leaf.Duplicate
+				l.Duplicate = &dup
+			}
+			clone.Path[h][i] = l
+		}
+	}
+	return clone
 }
 
 // Hex converts the MerklePath to a hexadecimal string representation
@@ -208,6 +253,9 @@ func (mp *MerklePath) ComputeRootHex(txidStr *string) (string, error) {
 
 // ComputeRoot computes the Merkle root from a given transaction ID
 func (mp *MerklePath) ComputeRoot(txid *chainhash.Hash) (*chainhash.Hash, error) {
+	if len(mp.Path) == 0 {
+		return nil, errors.New("the BUMP does not have any level")
+	}
 	if txid == nil {
 		for _, l := range mp.Path[0] {
 			if l.Hash != nil {
@@ -215,6 +263,9 @@ func (mp *MerklePath) ComputeRoot(txid *chainhash.Hash) (*chainhash.Hash, error)
 				break
 			}
 		}
+		if txid == nil {
+			return nil, errors.New("the BUMP does not contain any txid")
+		}
 	}
 	if len(mp.Path) == 1 {
 		// if there is only one txid in the block then the root is the txid.
@@ -222,14 +273,7 @@ func (mp *MerklePath) ComputeRoot(txid *chainhash.Hash) (*chainhash.Hash, error)
 			return txid, nil
 		}
 	}
-	indexedPath := make(IndexedPath, len(mp.Path))
-	for h := 0; h < len(mp.Path); h++ {
-		path := map[uint64]*PathElement{}
-		for l := 0; l < len(mp.Path[h]); l++ {
-			path[mp.Path[h][l].Offset] = mp.Path[h][l]
-		}
-		indexedPath[h] = path
-	}
+	indexedPath := mp.indexedPath()
 
 	// Find the index of the txid at the lowest level of the Merkle tree
 	var txLeaf *PathElement
@@ -255,6 +299,9 @@ func (mp *MerklePath) ComputeRoot(txid *chainhash.Hash) (*chainhash.Hash, error)
 		}
 		var digest []byte
 
+		if leaf.Hash == nil && (leaf.Duplicate == nil || !*leaf.Duplicate) {
+			return nil, fmt.Errorf("the BUMP has no hash for offset %d at height: %v", offset, height)
+		}
 		if leaf.Duplicate != nil && *leaf.Duplicate {
 			digest = append(workingHash.CloneBytes(), workingHash.CloneBytes()...)
 		} else {
@@ -281,12 +328,21 @@ func (mp *MerklePath) VerifyHex(txidStr string, ct chaintracker.ChainTracker) (b
 	}
 }
 
+// Verify is like VerifyContext with a background context.
 func (mp *MerklePath) Verify(txid *chainhash.Hash, ct chaintracker.ChainTracker) (bool, error) {
+	return mp.VerifyContext(context.Background(), txid, ct)
+}
+
+// VerifyContext checks that the merkle root computed for txid is the merkle
+// root of the block at the path's height according to the chain tracker.
+// Errors of the chain tracker are returned as is, so that a failure to
+// reach it can be told apart from an invalid path.
+func (mp *MerklePath) VerifyContext(ctx context.Context, txid *chainhash.Hash, ct chaintracker.ChainTracker) (bool, error) {
 	root, err := mp.ComputeRoot(txid)
 	if err != nil {
 		return false, err
 	}
-	return ct.IsValidRootForHeight(root, mp.BlockHeight), nil
+	return ct.IsValidRootForHeight(ctx, root, mp.BlockHeight)
 }
 
 func (m *MerklePath) Combine(other *MerklePath) (err error) {
@@ -343,3 +399,137 @@ func (m *MerklePath) Combine(other *MerklePath) (err error) {
 
 	return
 }
+
+// Extract returns a new MerklePath proving only the given txids, which must
+// all be leaves of mp. Other leaves are only kept as far as they are needed
+// to compute the root, without their txid flag, so the returned path does
+// not tell which other transactions of the block mp was proving.
+func (mp *MerklePath) Extract(txids ...*chainhash.Hash) (*MerklePath, error) {
+	if len(txids) == 0 {
+		return nil, errors.New("no txid to extract")
+	}
+	root, err := mp.ComputeRoot(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexedPath := mp.indexedPath()
+	levels := make([]map[uint64]*PathElement, len(mp.Path))
+	for h := range levels {
+		levels[h] = make(map[uint64]*PathElement)
+	}
+	for _, txid := range txids {
+		i := slices.IndexFunc(mp.Path[0], func(leaf *PathElement) bool {
+			return leaf.Hash != nil && leaf.Hash.IsEqual(txid)
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("the BUMP does not contain the txid: %s", txid)
+		}
+		isTxid := true
+		offset := mp.Path[0][i].Offset
+		levels[0][offset] = &PathElement{Offset: offset, Hash: txid, Txid: &isTxid}
+
+		for h := range levels {
+			sibling := (offset >> h) ^ 1
+			if _, ok := levels[h][sibling]; ok {
+				continue
+			}
+			if leaf := indexedPath.GetOffsetLeaf(h, sibling); leaf != nil {
+				levels[h][sibling] = &PathElement{Offset: sibling, Hash: leaf.Hash, Duplicate: leaf.Duplicate}
+			}
+		}
+	}
+
+	extracted := &MerklePath{
+		BlockHeight: mp.BlockHeight,
+		Path:        make([][]*PathElement, len(levels)),
+	}
+	for h, level := range levels {
+		extracted.Path[h] = make([]*PathElement, 0, len(level))
+		for _, leaf := range level {
+			extracted.Path[h] = append(extracted.Path[h], leaf)
+		}
+		slices.SortFunc(extracted.Path[h], func(a, b *PathElement) int {
+			return cmp.Compare(a.Offset, b.Offset)
+		})
+	}
+	extracted = extracted.Clone()
+	extracted.Trim()
+
+	for _, txid := range txids {
+		extractedRoot, err := extracted.ComputeRoot(txid)
+		if err != nil {
+			return nil, err
+		}
+		if !extractedRoot.IsEqual(root) {
+			return nil, fmt.Errorf("the extracted BUMP has a different root for txid: %s", txid)
+		}
+	}
+	return extracted, nil
+}
+
+// Trim removes the leaves which are not needed to prove the leaves flagged
+// as txid, keeping at each level only the siblings of the nodes computed
+// from the txids, along with the txids themselves. A level which would
+// become empty keeps its first leaf, as empty levels can not be serialized.
+// The order of the leaves kept is preserved. A path without any txid flag
+// is left untouched.
+func (mp *MerklePath) Trim() {
+	if len(mp.Path) == 0 {
+		return
+	}
+
+	computed := make(map[uint64]struct{})
+	for _, leaf := range mp.Path[0] {
+		if leaf.Txid != nil && *leaf.Txid {
+			computed[leaf.Offset] = struct{}{}
+		}
+	}
+	if len(computed) == 0 {
+		return
+	}
+
+	for h := range mp.Path {
+		if h > 0 {
+			parents := make(map[uint64]struct{}, len(computed))
+			for offset := range computed {
+				parents[offset>>1] = struct{}{}
+			}
+			computed = parents
+		}
+		mp.Path[h] = trimLevel(mp.Path[h], func(leaf *PathElement) bool {
+			_, isComputed := computed[leaf.Offset]
+			_, isSibling := computed[leaf.Offset^1]
+			if h == 0 {
+				return !isComputed && !isSibling
+			}
+			return isComputed || !isSibling
+		})
+	}
+}
+
+// trimLevel removes the leaves for which drop returns true, keeping the
+// first one when all of them would be removed.
+func trimLevel(level []*PathElement, drop func(*PathElement) bool) []*PathElement {
+	kept := make([]*PathElement, 0, len(level))
+	for _, leaf := range level {
+		if !drop(leaf) {
+			kept = append(kept, leaf)
+		}
+	}
+	if len(kept) == 0 && len(level) > 0 {
+		kept = append(kept, level[0])
+	}
+	return kept
+}
+
+func (mp *MerklePath) indexedPath() IndexedPath {
+	indexedPath := make(IndexedPath, len(mp.Path))
+	for h, level := range mp.Path {
+		indexedPath[h] = make(map[uint64]*PathElement, len(level))
+		for _, leaf := range level {
+			indexedPath[h][leaf.Offset] = leaf
+		}
+	}
+	return indexedPath
+}
@@ -1,11 +1,16 @@
 package transaction
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"slices"
 	"testing"
 
 	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
 	"github.com/bitcoin-sv/go-sdk/transaction/testdata"
 	"github.com/stretchr/testify/require"
 )
@@ -96,12 +101,35 @@ func TestMerklePathComputeRootHex(t *testing.T) {
 type MyChainTracker struct{}
 
 // Implement the IsValidRootForHeight method on MyChainTracker.
-func (mct MyChainTracker) IsValidRootForHeight(root *chainhash.Hash, height uint32) bool {
+func (mct MyChainTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
 	// Convert BRC74Root hex string to a byte slice for comparison
 	// expectedRoot, _ := hex.DecodeString(BRC74Root)
 
 	// Assuming BRC74JSON.BlockHeight is of type uint64, and needs to be cast to uint64
-	return root.String() == BRC74Root && height == BRC74JSON.BlockHeight
+	return root.String() == BRC74Root && height == BRC74JSON.BlockHeight, nil
+}
+
+// Implement the CurrentHeight method on MyChainTracker.
+func (mct MyChainTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	return BRC74JSON.BlockHeight, nil
+}
+
+// legacyTracker implements the former ChainTracker interface.
+type legacyTracker struct{}
+
+func (legacyTracker) IsValidRootForHeight(root *chainhash.Hash, height uint32) bool {
+	return root.String() == BRC74Root
+}
+
+// failingTracker can not be reached.
+type failingTracker struct{ err error }
+
+func (f failingTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	return false, f.err
+}
+
+func (f failingTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	return 0, f.err
 }
 
 func TestMerklePath_Verify(t *testing.T) {
@@ -118,6 +146,32 @@ func TestMerklePath_Verify(t *testing.T) {
 		require.True(t, result)
 	})
 
+	t.Run("verifies using a legacy ChainTracker", func(t *testing.T) {
+		path, err := NewMerklePathFromHex(BRC74Hex)
+		require.NoError(t, err)
+		tracker := chaintracker.FromLegacy(legacyTracker{})
+		result, err := path.VerifyContext(context.Background(), hexToChainhash(BRC74TXID1), tracker)
+		require.NoError(t, err)
+		require.True(t, result)
+
+		_, err = tracker.CurrentHeight(context.Background())
+		require.ErrorIs(t, err, chaintracker.ErrCurrentHeightUnsupported)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = path.VerifyContext(ctx, hexToChainhash(BRC74TXID1), tracker)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("returns chain tracker errors", func(t *testing.T) {
+		path, err := NewMerklePathFromHex(BRC74Hex)
+		require.NoError(t, err)
+		unavailable := errors.New("unavailable")
+		result, err := path.VerifyHex(BRC74TXID1, failingTracker{unavailable})
+		require.ErrorIs(t, err, unavailable)
+		require.False(t, result)
+	})
+
 }
 
 func TestMerklePathCombine(t *testing.T) {
@@ -185,3 +239,153 @@ func TestMerklePathCombine(t *testing.T) {
 		}
 	})
 }
+
+func TestMerklePathExtract(t *testing.T) {
+	t.Parallel()
+
+	t.Run("extracts a single txid", func(t *testing.T) {
+		mp, err := NewMerklePathFromHex(BRC74Hex)
+		require.NoError(t, err)
+		txid2 := hexToChainhash(BRC74TXID2)
+		txid3 := hexToChainhash(BRC74TXID3)
+
+		extracted, err := mp.Extract(txid2)
+		require.NoError(t, err)
+		require.Len(t, extracted.Path[0], 2)
+		for _, level := range extracted.Path[1:] {
+			require.Len(t, level, 1)
+		}
+		root, err := extracted.ComputeRootHex(&BRC74TXID2)
+		require.NoError(t, err)
+		require.Equal(t, BRC74Root, root)
+		_, err = extracted.ComputeRoot(txid3)
+		require.Error(t, err)
+		for _, leaf := range extracted.Path[0] {
+			require.False(t, leaf.Hash.IsEqual(txid3))
+		}
+
+		// The original path is left untouched.
+		require.Equal(t, BRC74Hex, mp.Hex())
+
+		_, err = mp.Extract(hexToChainhash(BRC74Root))
+		require.Error(t, err)
+	})
+
+	t.Run("matches the path built for the txid", func(t *testing.T) {
+		txids := make([]*chainhash.Hash, 13)
+		for i := range txids {
+			txids[i] = &chainhash.Hash{byte(i)}
+		}
+		compound, err := NewMerklePathFromBlockTxids(1, txids, txids...)
+		require.NoError(t, err)
+
+		for _, txid := range txids {
+			expected, err := NewMerklePathFromBlockTxids(1, txids, txid)
+			require.NoError(t, err)
+			extracted, err := compound.Extract(txid)
+			require.NoError(t, err)
+			require.Equal(t, expected, extracted)
+		}
+
+		root, err := MerkleRootFromTxids(txids)
+		require.NoError(t, err)
+		extracted, err := compound.Extract(txids[3], txids[12])
+		require.NoError(t, err)
+		require.Len(t, extracted.Path[0], 4)
+		parsed, err := NewMerklePathFromBinary(extracted.Bytes())
+		require.NoError(t, err)
+		for _, txid := range []*chainhash.Hash{txids[3], txids[12]} {
+			computed, err := parsed.ComputeRoot(txid)
+			require.NoError(t, err)
+			require.Equal(t, root, computed)
+		}
+	})
+}
+
+func TestMerklePathTrim(t *testing.T) {
+	t.Parallel()
+
+	mp, err := NewMerklePathFromHex(BRC74Hex)
+	require.NoError(t, err)
+	mp.Trim()
+
+	// Both txids are kept with their siblings, and the second level which
+	// can be fully computed keeps a single leaf.
+	require.Len(t, mp.Path[0], 4)
+	require.Len(t, mp.Path[1], 1)
+	parsed, err := NewMerklePathFromBinary(mp.Bytes())
+	require.NoError(t, err)
+	for _, txid := range []string{BRC74TXID2, BRC74TXID3} {
+		root, err := parsed.ComputeRootHex(&txid)
+		require.NoError(t, err)
+		require.Equal(t, BRC74Root, root)
+	}
+
+	// Leaves which are neither a txid nor the sibling of one are dropped.
+	mp, err = NewMerklePathFromHex(BRC74Hex)
+	require.NoError(t, err)
+	for _, leaf := range mp.Path[0] {
+		if leaf.Hash != nil && leaf.Hash.String() == BRC74TXID3 {
+			leaf.Txid = nil
+		}
+	}
+	mp.Trim()
+	require.Len(t, mp.Path[0], 2)
+	require.Len(t, mp.Path[1], 1)
+	root, err := mp.ComputeRootHex(&BRC74TXID2)
+	require.NoError(t, err)
+	require.Equal(t, BRC74Root, root)
+}
+
+func TestMerklePathTrimToTxid(t *testing.T) {
+	t.Parallel()
+
+	txids := make([]*chainhash.Hash, 13)
+	for i := range txids {
+		txids[i] = &chainhash.Hash{byte(i)}
+	}
+	// The path proving several txids holds the leaves of the path of each
+	// of them, including those the others allow to compute.
+	proven := []*chainhash.Hash{txids[0], txids[3], txids[6], txids[12]}
+	union := &MerklePath{BlockHeight: 1}
+	for _, txid := range proven {
+		mp, err := NewMerklePathFromBlockTxids(1, txids, txid)
+		require.NoError(t, err)
+		union.Path = append(union.Path, make([][]*PathElement, len(mp.Path)-len(union.Path))...)
+		for h, level := range mp.Path {
+			for _, leaf := range level {
+				i, found := slices.BinarySearchFunc(union.Path[h], leaf.Offset, func(e *PathElement, offset uint64) int {
+					return cmp.Compare(e.Offset, offset)
+				})
+				if !found {
+					union.Path[h] = slices.Insert(union.Path[h], i, leaf)
+				}
+			}
+		}
+	}
+
+	for _, txid := range proven {
+		expected, err := union.Extract(txid)
+		require.NoError(t, err)
+
+		trimmed := union.Clone()
+		for _, leaf := range trimmed.Path[0] {
+			if !leaf.Hash.IsEqual(txid) {
+				leaf.Txid = nil
+			}
+		}
+		// Levels in another order are neither sorted nor changed.
+		levels := make([][]*PathElement, len(trimmed.Path))
+		for h, level := range trimmed.Path {
+			slices.Reverse(level)
+			levels[h] = slices.Clone(level)
+		}
+		original := slices.Clone(trimmed.Path)
+		trimmed.Trim()
+		for h := range levels {
+			require.Equal(t, levels[h], original[h])
+			slices.Reverse(trimmed.Path[h])
+		}
+		require.Equal(t, expected, trimmed)
+	}
+}
@@ -0,0 +1,382 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/pkg/errors"
+)
+
+// Target types of a TSC merkle proof.
+const (
+	TSCTargetHash       = "hash"
+	TSCTargetHeader     = "header"
+	TSCTargetMerkleRoot = "merkleRoot"
+)
+
+// tscDuplicate stands for the working hash being paired with itself.
+const tscDuplicate = "*"
+
+// Flags and node types of the binary TSC merkle proof format.
+const (
+	tscFlagTx         byte = 0x01
+	tscFlagHeader     byte = 0x02
+	tscFlagMerkleRoot byte = 0x04
+	tscFlagTargetMask byte = 0x06
+	tscFlagTree       byte = 0x08
+	tscFlagComposite  byte = 0x10
+
+	tscNodeHash      byte = 0
+	tscNodeDuplicate byte = 1
+)
+
+// TSCProof is a single transaction merkle proof in the format standardised
+// by the Technical Standards Committee (https://brc.dev/10), which is still
+// used by many services and stored proofs. It can be converted to and from
+// a MerklePath, the format used by BEEF.
+//
+// Only branch proofs for a single transaction are supported, which are the
+// only ones in use.
+type TSCProof struct {
+	// Index is the position of the transaction in the block.
+	Index uint64 `json:"index"`
+	// TxOrID is either the txid or the full transaction, hex encoded.
+	TxOrID string `json:"txOrId"`
+	// TargetType is one of TSCTargetHash, TSCTargetHeader and
+	// TSCTargetMerkleRoot, an empty value meaning TSCTargetHash.
+	TargetType string `json:"targetType,omitempty"`
+	// Target is the block hash, block header or merkle root, hex encoded.
+	Target string `json:"target"`
+	// Nodes are the hashes to pair with the working hash from the
+	// transaction up to the root, "*" meaning the working hash itself.
+	Nodes     []string `json:"nodes"`
+	ProofType string   `json:"proofType,omitempty"`
+	Composite bool     `json:"composite,omitempty"`
+}
+
+// TSCHeightLookup returns the height of the block targeted by a TSC proof,
+// typically by looking up the block hash, header or merkle root in a header
+// service.
+type TSCHeightLookup func(proof *TSCProof) (uint32, error)
+
+// NewTSCProofFromBytes parses a TSC proof in its binary format.
+func NewTSCProofFromBytes(b []byte) (*TSCProof, error) {
+	reader := bytes.NewReader(b)
+	flags, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if flags&tscFlagTree != 0 {
+		return nil, errors.New("TSC proofs of type tree are not supported")
+	}
+	if flags&tscFlagComposite != 0 {
+		return nil, errors.New("composite TSC proofs are not supported")
+	}
+
+	p := &TSCProof{}
+	var index VarInt
+	if _, err = index.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	p.Index = uint64(index)
+
+	if flags&tscFlagTx != 0 {
+		var txLength VarInt
+		if _, err = txLength.ReadFrom(reader); err != nil {
+			return nil, err
+		}
+		tx, _, err := readBytes(reader, txLength)
+		if err != nil {
+			return nil, err
+		}
+		p.TxOrID = hex.EncodeToString(tx)
+	} else {
+		txid, err := readTSCHash(reader)
+		if err != nil {
+			return nil, err
+		}
+		p.TxOrID = txid
+	}
+
+	switch flags & tscFlagTargetMask {
+	case 0:
+		p.TargetType = TSCTargetHash
+		p.Target, err = readTSCHash(reader)
+	case tscFlagHeader:
+		p.TargetType = TSCTargetHeader
+		header := make([]byte, 80)
+		if _, err = io.ReadFull(reader, header); err == nil {
+			p.Target = hex.EncodeToString(header)
+		}
+	case tscFlagMerkleRoot:
+		p.TargetType = TSCTargetMerkleRoot
+		p.Target, err = readTSCHash(reader)
+	default:
+		return nil, fmt.Errorf("invalid TSC target type flags %x", flags)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeCount VarInt
+	if _, err = nodeCount.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	// The number of nodes is untrusted, a tree is at most 64 levels high.
+	if nodeCount > 64 {
+		return nil, fmt.Errorf("too many TSC proof nodes: %d", nodeCount)
+	}
+	p.Nodes = make([]string, 0, nodeCount)
+	for i := 0; i < int(nodeCount); i++ {
+		nodeType, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch nodeType {
+		case tscNodeHash:
+			node, err := readTSCHash(reader)
+			if err != nil {
+				return nil, err
+			}
+			p.Nodes = append(p.Nodes, node)
+		case tscNodeDuplicate:
+			p.Nodes = append(p.Nodes, tscDuplicate)
+		default:
+			return nil, fmt.Errorf("unsupported TSC proof node type %d", nodeType)
+		}
+	}
+
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("%d trailing bytes after TSC proof", reader.Len())
+	}
+	return p, nil
+}
+
+// NewTSCProofFromMerklePath creates a TSC proof of the transaction with the
+// given txid, targeting the merkle root computed from the path.
+func NewTSCProofFromMerklePath(mp *MerklePath, txid *chainhash.Hash) (*TSCProof, error) {
+	root, err := mp.ComputeRoot(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	var index uint64
+	for _, leaf := range mp.Path[0] {
+		if leaf.Hash != nil && leaf.Hash.IsEqual(txid) {
+			index = leaf.Offset
+			break
+		}
+	}
+
+	p := &TSCProof{
+		Index:      index,
+		TxOrID:     txid.String(),
+		TargetType: TSCTargetMerkleRoot,
+		Target:     root.String(),
+		Nodes:      make([]string, 0, len(mp.Path)),
+	}
+	// A block with a single transaction has no node to pair with.
+	if len(mp.Path) == 1 && len(mp.Path[0]) == 1 {
+		return p, nil
+	}
+
+	indexedPath := mp.indexedPath()
+	for h := range mp.Path {
+		leaf := indexedPath.GetOffsetLeaf(h, (index>>h)^1)
+		switch {
+		case leaf == nil:
+			return nil, fmt.Errorf("the BUMP has no sibling for offset %d at height %d", index>>h, h)
+		case leaf.Duplicate != nil && *leaf.Duplicate:
+			p.Nodes = append(p.Nodes, tscDuplicate)
+		default:
+			p.Nodes = append(p.Nodes, leaf.Hash.String())
+		}
+	}
+	return p, nil
+}
+
+// TxID returns the txid of the proven transaction.
+func (p *TSCProof) TxID() (*chainhash.Hash, error) {
+	if len(p.TxOrID) == chainhash.MaxHashStringSize {
+		return chainhash.NewHashFromHex(p.TxOrID)
+	}
+	tx, err := NewTransactionFromHex(p.TxOrID)
+	if err != nil {
+		return nil, err
+	}
+	return tx.TxID(), nil
+}
+
+// MerklePath converts the proof into a MerklePath for the block at the given
+// height. When the proof targets a merkle root or a header, the root
+// computed from the nodes must match it.
+func (p *TSCProof) MerklePath(blockHeight uint32) (*MerklePath, error) {
+	if p.ProofType != "" && p.ProofType != "branch" {
+		return nil, fmt.Errorf("TSC proofs of type %s are not supported", p.ProofType)
+	}
+	if p.Composite {
+		return nil, errors.New("composite TSC proofs are not supported")
+	}
+	if len(p.Nodes) > 64 {
+		return nil, fmt.Errorf("too many TSC proof nodes: %d", len(p.Nodes))
+	}
+	txid, err := p.TxID()
+	if err != nil {
+		return nil, err
+	}
+
+	isTxid := true
+	path := make([][]*PathElement, max(len(p.Nodes), 1))
+	path[0] = []*PathElement{{Offset: p.Index, Hash: txid, Txid: &isTxid}}
+	for h, node := range p.Nodes {
+		leaf := &PathElement{Offset: (p.Index >> h) ^ 1}
+		if node == tscDuplicate {
+			isDuplicate := true
+			leaf.Duplicate = &isDuplicate
+		} else if leaf.Hash, err = chainhash.NewHashFromHex(node); err != nil {
+			return nil, err
+		}
+		path[h] = append(path[h], leaf)
+	}
+	mp := NewMerklePath(blockHeight, path)
+	for _, level := range mp.Path {
+		if len(level) == 2 && level[1].Offset < level[0].Offset {
+			level[0], level[1] = level[1], level[0]
+		}
+	}
+
+	root, err := mp.ComputeRoot(txid)
+	if err != nil {
+		return nil, err
+	}
+	if expected, err := p.merkleRoot(); err != nil {
+		return nil, err
+	} else if expected != nil && !expected.IsEqual(root) {
+		return nil, fmt.Errorf("TSC proof computes root %s instead of %s", root, expected)
+	}
+	return mp, nil
+}
+
+// MerklePathWithLookup converts the proof into a MerklePath, looking up the
+// height of the targeted block.
+func (p *TSCProof) MerklePathWithLookup(lookup TSCHeightLookup) (*MerklePath, error) {
+	blockHeight, err := lookup(p)
+	if err != nil {
+		return nil, err
+	}
+	return p.MerklePath(blockHeight)
+}
+
+// Bytes encodes the proof in the binary TSC format.
+func (p *TSCProof) Bytes() ([]byte, error) {
+	if p.ProofType != "" && p.ProofType != "branch" {
+		return nil, fmt.Errorf("TSC proofs of type %s are not supported", p.ProofType)
+	}
+	if p.Composite {
+		return nil, errors.New("composite TSC proofs are not supported")
+	}
+
+	var flags byte
+	var target []byte
+	switch p.TargetType {
+	case "", TSCTargetHash:
+		hash, err := chainhash.NewHashFromHex(p.Target)
+		if err != nil {
+			return nil, err
+		}
+		target = hash.CloneBytes()
+	case TSCTargetHeader:
+		header, err := hex.DecodeString(p.Target)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) != 80 {
+			return nil, fmt.Errorf("invalid TSC header target length %d", len(header))
+		}
+		flags |= tscFlagHeader
+		target = header
+	case TSCTargetMerkleRoot:
+		root, err := chainhash.NewHashFromHex(p.Target)
+		if err != nil {
+			return nil, err
+		}
+		flags |= tscFlagMerkleRoot
+		target = root.CloneBytes()
+	default:
+		return nil, fmt.Errorf("invalid TSC target type %s", p.TargetType)
+	}
+
+	var txOrID []byte
+	if len(p.TxOrID) == chainhash.MaxHashStringSize {
+		txid, err := chainhash.NewHashFromHex(p.TxOrID)
+		if err != nil {
+			return nil, err
+		}
+		txOrID = txid.CloneBytes()
+	} else {
+		tx, err := hex.DecodeString(p.TxOrID)
+		if err != nil {
+			return nil, err
+		}
+		flags |= tscFlagTx
+		txOrID = append(VarInt(len(tx)).Bytes(), tx...)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(flags)
+	buf.Write(VarInt(p.Index).Bytes())
+	buf.Write(txOrID)
+	buf.Write(target)
+	buf.Write(VarInt(len(p.Nodes)).Bytes())
+	for _, node := range p.Nodes {
+		if node == tscDuplicate {
+			buf.WriteByte(tscNodeDuplicate)
+			continue
+		}
+		hash, err := chainhash.NewHashFromHex(node)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(tscNodeHash)
+		buf.Write(hash.CloneBytes())
+	}
+	return buf.Bytes(), nil
+}
+
+// merkleRoot returns the merkle root the proof targets, or nil when it
+// targets a block hash.
+func (p *TSCProof) merkleRoot() (*chainhash.Hash, error) {
+	switch p.TargetType {
+	case "", TSCTargetHash:
+		return nil, nil
+	case TSCTargetMerkleRoot:
+		return chainhash.NewHashFromHex(p.Target)
+	case TSCTargetHeader:
+		header, err := hex.DecodeString(p.Target)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) != 80 {
+			return nil, fmt.Errorf("invalid TSC header target length %d", len(header))
+		}
+		// The merkle root follows the version and the previous block hash.
+		return chainhash.NewHash(header[36:68])
+	default:
+		return nil, fmt.Errorf("invalid TSC target type %s", p.TargetType)
+	}
+}
+
+func readTSCHash(r io.Reader) (string, error) {
+	b := make([]byte, chainhash.HashSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	hash, err := chainhash.NewHash(b)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
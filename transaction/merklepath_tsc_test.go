@@ -0,0 +1,140 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTSCProof(t *testing.T) {
+	t.Parallel()
+
+	txids := make([]*chainhash.Hash, 13)
+	for i := range txids {
+		txids[i] = &chainhash.Hash{byte(i), 1}
+	}
+	root, err := MerkleRootFromTxids(txids)
+	require.NoError(t, err)
+
+	t.Run("converts to and from MerklePath", func(t *testing.T) {
+		for _, txid := range txids {
+			mp, err := NewMerklePathFromBlockTxids(100, txids, txid)
+			require.NoError(t, err)
+
+			proof, err := NewTSCProofFromMerklePath(mp, txid)
+			require.NoError(t, err)
+			require.Equal(t, TSCTargetMerkleRoot, proof.TargetType)
+			require.Equal(t, root.String(), proof.Target)
+			require.Len(t, proof.Nodes, 4)
+
+			converted, err := proof.MerklePath(100)
+			require.NoError(t, err)
+			require.Equal(t, mp, converted)
+		}
+		// The last transaction of the block is paired with itself.
+		mp, err := NewMerklePathFromBlockTxids(100, txids, txids[12])
+		require.NoError(t, err)
+		proof, err := NewTSCProofFromMerklePath(mp, txids[12])
+		require.NoError(t, err)
+		require.Equal(t, "*", proof.Nodes[0])
+	})
+
+	t.Run("round trips through JSON and binary", func(t *testing.T) {
+		mp, err := NewMerklePathFromBlockTxids(100, txids, txids[12])
+		require.NoError(t, err)
+		proof, err := NewTSCProofFromMerklePath(mp, txids[12])
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(proof)
+		require.NoError(t, err)
+		var decoded TSCProof
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		require.Equal(t, proof, &decoded)
+
+		b, err := proof.Bytes()
+		require.NoError(t, err)
+		parsed, err := NewTSCProofFromBytes(b)
+		require.NoError(t, err)
+		require.Equal(t, proof, parsed)
+
+		_, err = NewTSCProofFromBytes(append(b, 0))
+		require.Error(t, err)
+		for i := range b {
+			_, err = NewTSCProofFromBytes(b[:i])
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("accepts a full transaction and a header target", func(t *testing.T) {
+		tx := NewTransaction()
+		tx.AddOutput(&TransactionOutput{Satoshis: 1, LockingScript: &script.Script{script.OpTRUE}})
+		txs := append([]*chainhash.Hash{tx.TxID()}, txids[:4]...)
+		blockRoot, err := MerkleRootFromTxids(txs)
+		require.NoError(t, err)
+
+		header := make([]byte, 80)
+		copy(header[36:68], blockRoot.CloneBytes())
+		mp, err := NewMerklePathFromBlockTxids(7, txs, tx.TxID())
+		require.NoError(t, err)
+		proof, err := NewTSCProofFromMerklePath(mp, tx.TxID())
+		require.NoError(t, err)
+		proof.TxOrID = tx.Hex()
+		proof.TargetType = TSCTargetHeader
+		proof.Target = hex.EncodeToString(header)
+
+		b, err := proof.Bytes()
+		require.NoError(t, err)
+		parsed, err := NewTSCProofFromBytes(b)
+		require.NoError(t, err)
+		require.Equal(t, proof, parsed)
+
+		converted, err := parsed.MerklePathWithLookup(func(p *TSCProof) (uint32, error) {
+			require.Equal(t, proof.Target, p.Target)
+			return 7, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, mp, converted)
+
+		lookupErr := errors.New("unknown block")
+		_, err = parsed.MerklePathWithLookup(func(*TSCProof) (uint32, error) {
+			return 0, lookupErr
+		})
+		require.ErrorIs(t, err, lookupErr)
+	})
+
+	t.Run("rejects a proof for another root", func(t *testing.T) {
+		mp, err := NewMerklePathFromBlockTxids(100, txids, txids[3])
+		require.NoError(t, err)
+		proof, err := NewTSCProofFromMerklePath(mp, txids[3])
+		require.NoError(t, err)
+		proof.Nodes[1] = proof.Nodes[0]
+		_, err = proof.MerklePath(100)
+		require.Error(t, err)
+
+		// A block hash target is left to the chain tracker to check.
+		proof.TargetType = TSCTargetHash
+		_, err = proof.MerklePath(100)
+		require.NoError(t, err)
+
+		proof.Composite = true
+		_, err = proof.MerklePath(100)
+		require.Error(t, err)
+	})
+
+	t.Run("handles a block with a single transaction", func(t *testing.T) {
+		mp, err := NewMerklePathFromBlockTxids(1, txids[:1], txids[0])
+		require.NoError(t, err)
+		proof, err := NewTSCProofFromMerklePath(mp, txids[0])
+		require.NoError(t, err)
+		require.Empty(t, proof.Nodes)
+		require.Equal(t, txids[0].String(), proof.Target)
+		converted, err := proof.MerklePath(1)
+		require.NoError(t, err)
+		require.Equal(t, mp, converted)
+	})
+}
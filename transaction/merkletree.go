@@ -0,0 +1,132 @@
+package transaction
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	crypto "github.com/bitcoin-sv/go-sdk/primitives/hash"
+	"github.com/pkg/errors"
+)
+
+// MerkleRootFromTxids computes the merkle root of a block from the txids of
+// its transactions, in block order. It allows checking a txid list against
+// the merkle root of a block header.
+func MerkleRootFromTxids(txids []*chainhash.Hash) (*chainhash.Hash, error) {
+	tree, err := merkleTree(txids)
+	if err != nil {
+		return nil, err
+	}
+	return tree[len(tree)-1][0], nil
+}
+
+// NewMerklePathFromBlockTxids creates the MerklePath proving the subject
+// transactions, given the txids of every transaction of the block at
+// blockHeight in block order. Several subjects result in a compound path
+// holding only the hashes which can not be computed from other leaves.
+func NewMerklePathFromBlockTxids(blockHeight uint32, txids []*chainhash.Hash, subjects ...*chainhash.Hash) (*MerklePath, error) {
+	if len(subjects) == 0 {
+		return nil, errors.New("no subject txid given")
+	}
+	tree, err := merkleTree(txids)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[chainhash.Hash]uint64, len(txids))
+	for i, txid := range txids {
+		offsets[*txid] = uint64(i)
+	}
+
+	// The root is not part of the path, but a block with a single
+	// transaction still needs a level holding it.
+	treeHeight := max(len(tree)-1, 1)
+	path := make([][]*PathElement, treeHeight)
+
+	// computed holds, for each level, the offsets of the nodes which are
+	// computed from the subjects and thus left out of the path.
+	computed := make([]map[uint64]struct{}, treeHeight)
+	for h := range computed {
+		computed[h] = make(map[uint64]struct{})
+	}
+	for _, subject := range subjects {
+		offset, ok := offsets[*subject]
+		if !ok {
+			return nil, fmt.Errorf("subject %s is not in the block", subject)
+		}
+		if _, ok := computed[0][offset]; ok {
+			continue
+		}
+		isTxid := true
+		path[0] = append(path[0], &PathElement{Offset: offset, Hash: subject, Txid: &isTxid})
+		for h := 0; h < treeHeight; h++ {
+			computed[h][offset>>h] = struct{}{}
+		}
+	}
+
+	for h := 0; h < len(tree)-1; h++ {
+		siblings := make(map[uint64]struct{})
+		for offset := range computed[h] {
+			sibling := offset ^ 1
+			if _, ok := computed[h][sibling]; ok {
+				continue
+			}
+			siblings[sibling] = struct{}{}
+		}
+		for sibling := range siblings {
+			leaf := &PathElement{Offset: sibling}
+			if sibling < uint64(len(tree[h])) {
+				leaf.Hash = tree[h][sibling]
+			} else {
+				isDuplicate := true
+				leaf.Duplicate = &isDuplicate
+			}
+			path[h] = append(path[h], leaf)
+		}
+		// Every level of a serialized BUMP must hold a leaf, so a level
+		// fully computed from the levels below repeats one of its nodes.
+		if len(path[h]) == 0 {
+			offset := uint64(len(tree[h]))
+			for o := range computed[h] {
+				offset = min(offset, o)
+			}
+			path[h] = append(path[h], &PathElement{Offset: offset, Hash: tree[h][offset]})
+		}
+	}
+
+	for _, level := range path {
+		slices.SortFunc(level, func(a, b *PathElement) int {
+			return cmp.Compare(a.Offset, b.Offset)
+		})
+	}
+	return NewMerklePath(blockHeight, path), nil
+}
+
+// merkleTree returns every level of the merkle tree built from txids, from
+// the txids up to the root. The last node of a level with an odd number of
+// nodes is paired with itself.
+func merkleTree(txids []*chainhash.Hash) ([][]*chainhash.Hash, error) {
+	if len(txids) == 0 {
+		return nil, errors.New("a block holds at least one transaction")
+	}
+	tree := [][]*chainhash.Hash{txids}
+	for level := txids; len(level) > 1; {
+		next := make([]*chainhash.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			left, right := level[i], level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			digest := append(left.CloneBytes(), right.CloneBytes()...)
+			hash, err := chainhash.NewHash(crypto.Sha256d(digest))
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, hash)
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree, nil
+}
@@ -0,0 +1,123 @@
+package transaction
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// Block 100000 of the original chain.
+var block100000Txids = []string{
+	"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+	"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+	"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+	"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+}
+
+const block100000Root = "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+
+func randomTxids(t *testing.T, n int) []*chainhash.Hash {
+	txids := make([]*chainhash.Hash, n)
+	for i := range txids {
+		txids[i] = &chainhash.Hash{}
+		_, err := rand.Read(txids[i][:])
+		require.NoError(t, err)
+	}
+	return txids
+}
+
+func TestMerkleRootFromTxids(t *testing.T) {
+	t.Parallel()
+
+	txids := make([]*chainhash.Hash, len(block100000Txids))
+	for i, txid := range block100000Txids {
+		txids[i] = hexToChainhash(txid)
+	}
+	root, err := MerkleRootFromTxids(txids)
+	require.NoError(t, err)
+	require.Equal(t, block100000Root, root.String())
+
+	root, err = MerkleRootFromTxids(txids[:1])
+	require.NoError(t, err)
+	require.Equal(t, txids[0], root)
+
+	_, err = MerkleRootFromTxids(nil)
+	require.Error(t, err)
+}
+
+func TestNewMerklePathFromBlockTxids(t *testing.T) {
+	t.Parallel()
+
+	t.Run("proves every transaction", func(t *testing.T) {
+		for _, n := range []int{1, 2, 3, 5, 8, 13, 100} {
+			txids := randomTxids(t, n)
+			root, err := MerkleRootFromTxids(txids)
+			require.NoError(t, err)
+
+			for _, txid := range txids {
+				mp, err := NewMerklePathFromBlockTxids(100, txids, txid)
+				require.NoError(t, err)
+				require.Equal(t, uint32(100), mp.BlockHeight)
+
+				computed, err := mp.ComputeRoot(txid)
+				require.NoError(t, err)
+				require.Equal(t, root, computed)
+
+				parsed, err := NewMerklePathFromBinary(mp.Bytes())
+				require.NoError(t, err)
+				computed, err = parsed.ComputeRoot(txid)
+				require.NoError(t, err)
+				require.Equal(t, root, computed)
+			}
+		}
+	})
+
+	t.Run("marks odd levels with the duplicate flag", func(t *testing.T) {
+		txids := randomTxids(t, 5)
+		mp, err := NewMerklePathFromBlockTxids(1, txids, txids[4])
+		require.NoError(t, err)
+		require.Len(t, mp.Path, 3)
+		for _, level := range mp.Path[:2] {
+			last := level[len(level)-1]
+			require.NotNil(t, last.Duplicate)
+			require.True(t, *last.Duplicate)
+			require.Nil(t, last.Hash)
+		}
+	})
+
+	t.Run("builds a compact compound path", func(t *testing.T) {
+		txids := randomTxids(t, 8)
+		root, err := MerkleRootFromTxids(txids)
+		require.NoError(t, err)
+
+		mp, err := NewMerklePathFromBlockTxids(1, txids, txids[0], txids[1], txids[5])
+		require.NoError(t, err)
+		// txids 0 and 1 are each other's sibling, so the first level only
+		// adds the sibling of 5, and the second level the siblings of the
+		// pairs holding 1 and 5. The third level can be fully computed but
+		// still holds a node, as empty levels can not be serialized.
+		require.Len(t, mp.Path[0], 4)
+		require.Len(t, mp.Path[1], 2)
+		require.Len(t, mp.Path[2], 1)
+
+		parsed, err := NewMerklePathFromBinary(mp.Bytes())
+		require.NoError(t, err)
+		for _, subject := range []*chainhash.Hash{txids[0], txids[1], txids[5]} {
+			computed, err := parsed.ComputeRoot(subject)
+			require.NoError(t, err)
+			require.Equal(t, root, computed)
+		}
+		_, err = mp.ComputeRoot(txids[2])
+		require.Error(t, err)
+	})
+
+	t.Run("rejects unknown subjects", func(t *testing.T) {
+		txids := randomTxids(t, 4)
+		_, err := NewMerklePathFromBlockTxids(1, txids, randomTxids(t, 1)[0])
+		require.Error(t, err)
+		_, err = NewMerklePathFromBlockTxids(1, txids)
+		require.Error(t, err)
+	})
+}
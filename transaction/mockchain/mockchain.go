@@ -0,0 +1,343 @@
+// Package mockchain implements an in-memory chain for tests, which accepts
+// transactions through the Broadcaster interface, validates them against
+// its UTXO set and mines them into blocks with merkle paths. It is also a
+// ChainTracker agreeing with those merkle paths, so that transactions can be
+// created, broadcast, proven and verified without any service.
+package mockchain
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/go-sdk/block"
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker/headerchain"
+)
+
+// regtestBits is the easiest target of regtest, so that mining a block
+// takes a couple of hashes.
+const regtestBits = 0x207fffff
+
+var (
+	// ErrAlreadyKnown is returned when broadcasting a transaction the chain
+	// already holds.
+	ErrAlreadyKnown = errors.New("txn-already-known")
+	// ErrMissingInputs is returned when a transaction spends an output the
+	// chain does not know.
+	ErrMissingInputs = errors.New("missing-inputs")
+	// ErrDoubleSpend is returned when a transaction spends an output which
+	// is already spent.
+	ErrDoubleSpend = errors.New("txn-mempool-conflict")
+	// ErrInvalidTransaction is returned when a transaction is malformed,
+	// spends more than its inputs or fails script validation.
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	// ErrUnknownTransaction is returned when looking up a transaction the
+	// chain does not hold.
+	ErrUnknownTransaction = errors.New("unknown transaction")
+)
+
+type outpoint struct {
+	txid chainhash.Hash
+	vout uint32
+}
+
+// Chain is an in-memory chain. It is safe for concurrent use.
+type Chain struct {
+	headers *headerchain.Chain
+
+	mu      sync.Mutex
+	tip     *block.Header
+	height  uint32
+	txs     map[chainhash.Hash]*transaction.Transaction
+	utxos   map[outpoint]*transaction.TransactionOutput
+	spent   map[outpoint]chainhash.Hash
+	mempool []*transaction.Transaction
+}
+
+// New creates a chain holding a genesis block at height 0.
+func New() *Chain {
+	c := &Chain{
+		txs:   make(map[chainhash.Hash]*transaction.Transaction),
+		utxos: make(map[outpoint]*transaction.TransactionOutput),
+		spent: make(map[outpoint]chainhash.Hash),
+	}
+	genesis := &block.Header{
+		Version:   1,
+		Timestamp: uint32(time.Now().Unix()),
+		Bits:      regtestBits,
+	}
+	coinbase := c.coinbase(0)
+	if err := c.mine(genesis, coinbase); err != nil {
+		// Mining the genesis block only hashes a fixed amount of data.
+		panic(err)
+	}
+	c.headers = headerchain.NewWithPowLimit(genesis, 0, block.CompactToBig(regtestBits))
+	return c
+}
+
+// Fund mines a block whose coinbase transaction pays the outputs, and
+// returns that transaction along with its merkle path.
+func (c *Chain) Fund(outputs ...*transaction.TransactionOutput) (*transaction.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	coinbase := c.coinbase(c.height + 1)
+	coinbase.Outputs = outputs
+	if err := c.mineNext(coinbase); err != nil {
+		return nil, err
+	}
+	return coinbase, nil
+}
+
+// FundScript is like Fund with a single output paying satoshis to the
+// locking script.
+func (c *Chain) FundScript(lockingScript *script.Script, satoshis uint64) (*transaction.Transaction, error) {
+	return c.Fund(&transaction.TransactionOutput{
+		Satoshis:      satoshis,
+		LockingScript: lockingScript,
+	})
+}
+
+// Mine mines a block holding the transactions accepted since the last
+// block. Their MerklePath is set, on the very transactions which were
+// broadcast.
+func (c *Chain) Mine() (*block.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.mineNext(append([]*transaction.Transaction{c.coinbase(c.height + 1)}, c.mempool...)...); err != nil {
+		return nil, err
+	}
+	c.mempool = nil
+	return c.tip, nil
+}
+
+func (c *Chain) Broadcast(tx *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	return c.BroadcastContext(context.Background(), tx)
+}
+
+// BroadcastContext validates the transaction and adds it to the mempool,
+// to be mined by the next call to Mine.
+func (c *Chain) BroadcastContext(ctx context.Context, tx *transaction.Transaction) (*transaction.BroadcastSuccess, *transaction.BroadcastFailure) {
+	if err := ctx.Err(); err != nil {
+		return nil, &transaction.BroadcastFailure{Code: "500", Description: err.Error()}
+	}
+	if err := c.Accept(tx); err != nil {
+		code := "400"
+		if errors.Is(err, ErrDoubleSpend) || errors.Is(err, ErrMissingInputs) {
+			code = "460"
+		}
+		return nil, &transaction.BroadcastFailure{Code: code, Description: err.Error()}
+	}
+	return &transaction.BroadcastSuccess{
+		Txid:    tx.TxID().String(),
+		Message: "accepted",
+	}, nil
+}
+
+// Accept validates the transaction and adds it to the mempool.
+func (c *Chain) Accept(tx *transaction.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	txid := *tx.TxID()
+	if _, ok := c.txs[txid]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyKnown, txid)
+	}
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return fmt.Errorf("%w: transaction needs inputs and outputs", ErrInvalidTransaction)
+	}
+	if tx.IsCoinbase() {
+		return fmt.Errorf("%w: coinbase transactions can only be mined", ErrInvalidTransaction)
+	}
+
+	// Validate a copy spending the outputs of the UTXO set, whatever
+	// source transactions are attached to the inputs.
+	check := &transaction.Transaction{
+		Version:  tx.Version,
+		Outputs:  tx.Outputs,
+		LockTime: tx.LockTime,
+		Inputs:   make([]*transaction.TransactionInput, len(tx.Inputs)),
+	}
+	var inputSatoshis, outputSatoshis uint64
+	seen := make(map[outpoint]struct{}, len(tx.Inputs))
+	for vin, input := range tx.Inputs {
+		if input.SourceTXID == nil {
+			return fmt.Errorf("%w: input %d has no source txid", ErrInvalidTransaction, vin)
+		}
+		op := outpoint{txid: *input.SourceTXID, vout: input.SourceTxOutIndex}
+		if _, ok := seen[op]; ok {
+			return fmt.Errorf("%w: input %d spends %s:%d twice", ErrDoubleSpend, vin, op.txid, op.vout)
+		}
+		seen[op] = struct{}{}
+		if spender, ok := c.spent[op]; ok {
+			return fmt.Errorf("%w: %s:%d is spent by %s", ErrDoubleSpend, op.txid, op.vout, spender)
+		}
+		utxo, ok := c.utxos[op]
+		if !ok {
+			return fmt.Errorf("%w: %s:%d", ErrMissingInputs, op.txid, op.vout)
+		}
+		inputSatoshis += utxo.Satoshis
+
+		in := *input
+		in.SourceTransaction = nil
+		in.SetSourceTxOutput(utxo)
+		check.Inputs[vin] = &in
+	}
+	for _, output := range tx.Outputs {
+		outputSatoshis += output.Satoshis
+	}
+	if outputSatoshis > inputSatoshis {
+		return fmt.Errorf("%w: outputs spend %d satoshis out of %d", ErrInvalidTransaction, outputSatoshis, inputSatoshis)
+	}
+	for vin, input := range check.Inputs {
+		if input.UnlockingScript == nil {
+			return fmt.Errorf("%w: input %d is not signed", ErrInvalidTransaction, vin)
+		}
+		if err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(check, vin, input.SourceTxOutput()),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return fmt.Errorf("%w: input %d: %w", ErrInvalidTransaction, vin, err)
+		}
+	}
+
+	for _, input := range check.Inputs {
+		op := outpoint{txid: *input.SourceTXID, vout: input.SourceTxOutIndex}
+		delete(c.utxos, op)
+		c.spent[op] = txid
+	}
+	c.addOutputs(tx)
+	c.mempool = append(c.mempool, tx)
+	return nil
+}
+
+// IsValidRootForHeight reports whether root is the merkle root of the block
+// mined at the given height. It fails with headerchain.ErrHeightNotFound
+// for a height not mined yet.
+func (c *Chain) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	return c.headers.IsValidRootForHeight(ctx, root, height)
+}
+
+// CurrentHeight returns the height of the last block mined.
+func (c *Chain) CurrentHeight(ctx context.Context) (uint32, error) {
+	return c.headers.CurrentHeight(ctx)
+}
+
+// Headers returns the chain of block headers mined, which can be used as a
+// ChainTracker on its own.
+func (c *Chain) Headers() *headerchain.Chain {
+	return c.headers
+}
+
+// Transaction returns a transaction broadcast or mined, with its merkle path
+// once mined.
+func (c *Chain) Transaction(txid *chainhash.Hash) (*transaction.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tx, ok := c.txs[*txid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTransaction, txid)
+	}
+	return tx, nil
+}
+
+// IsUnspent reports whether the output is in the UTXO set, unspent by any
+// transaction mined or accepted in the mempool.
+func (c *Chain) IsUnspent(txid *chainhash.Hash, vout uint32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.utxos[outpoint{txid: *txid, vout: vout}]
+	return ok
+}
+
+// Mempool returns the transactions accepted since the last block.
+func (c *Chain) Mempool() []*transaction.Transaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*transaction.Transaction(nil), c.mempool...)
+}
+
+// coinbase returns a coinbase transaction for the block at height, which
+// pays nothing.
+func (c *Chain) coinbase(height uint32) *transaction.Transaction {
+	heightBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(heightBytes, height)
+	unlockingScript := &script.Script{}
+	_ = unlockingScript.AppendPushData(heightBytes)
+
+	tx := transaction.NewTransaction()
+	tx.AddInput(&transaction.TransactionInput{
+		SourceTXID:       &chainhash.Hash{},
+		SourceTxOutIndex: 0xffffffff,
+		SequenceNumber:   transaction.DefaultSequenceNumber,
+		UnlockingScript:  unlockingScript,
+	})
+	tx.AddOutput(&transaction.TransactionOutput{
+		LockingScript: &script.Script{script.OpFALSE, script.OpRETURN},
+	})
+	return tx
+}
+
+// mineNext mines the transactions in a block following the tip.
+func (c *Chain) mineNext(txs ...*transaction.Transaction) error {
+	header := &block.Header{
+		Version:   1,
+		PrevHash:  *c.tip.Hash(),
+		Timestamp: c.tip.Timestamp + 600,
+		Bits:      regtestBits,
+	}
+	if err := c.mine(header, txs...); err != nil {
+		return err
+	}
+	return c.headers.Add(header)
+}
+
+// mine completes the header of the block holding the transactions, sets
+// their merkle paths and makes the block the tip. The coinbase transaction
+// comes first, its outputs are added to the UTXO set.
+func (c *Chain) mine(header *block.Header, txs ...*transaction.Transaction) error {
+	height := uint32(0)
+	if c.tip != nil {
+		height = c.height + 1
+	}
+	txids := make([]*chainhash.Hash, len(txs))
+	for i, tx := range txs {
+		txids[i] = tx.TxID()
+	}
+	root, err := transaction.MerkleRootFromTxids(txids)
+	if err != nil {
+		return err
+	}
+	header.MerkleRoot = *root
+	limit := block.CompactToBig(header.Bits)
+	for header.CheckProofOfWorkLimit(limit) != nil {
+		header.Nonce++
+	}
+
+	for i, tx := range txs {
+		path, err := transaction.NewMerklePathFromBlockTxids(height, txids, txids[i])
+		if err != nil {
+			return err
+		}
+		tx.MerklePath = path
+	}
+	c.addOutputs(txs[0])
+	c.tip, c.height = header, height
+	return nil
+}
+
+// addOutputs adds the outputs of the transaction to the UTXO set.
+func (c *Chain) addOutputs(tx *transaction.Transaction) {
+	txid := *tx.TxID()
+	c.txs[txid] = tx
+	for vout, output := range tx.Outputs {
+		c.utxos[outpoint{txid: txid, vout: uint32(vout)}] = output
+	}
+}
@@ -0,0 +1,177 @@
+package mockchain_test
+
+import (
+	"context"
+	"testing"
+
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker/headerchain"
+	"github.com/bitcoin-sv/go-sdk/transaction/mockchain"
+	"github.com/bitcoin-sv/go-sdk/transaction/spv"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+type wallet struct {
+	lock     *script.Script
+	unlocker *p2pkh.P2PKH
+}
+
+func newWallet(t *testing.T) *wallet {
+	priv, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(priv.PubKey(), true)
+	require.NoError(t, err)
+	lock, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+	unlocker, err := p2pkh.Unlock(priv, nil)
+	require.NoError(t, err)
+	return &wallet{lock: lock, unlocker: unlocker}
+}
+
+// pay spends the first output of source to w, paying satoshis.
+func (w *wallet) pay(t *testing.T, source *transaction.Transaction, satoshis uint64) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(source, 0, w.unlocker)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: w.lock})
+	require.NoError(t, tx.Sign())
+	return tx
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("funds, broadcasts, mines and verifies", func(t *testing.T) {
+		chain := mockchain.New()
+		var _ chaintracker.ChainTracker = chain
+		var _ transaction.ContextBroadcaster = chain
+
+		w := newWallet(t)
+		funding, err := chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+		require.NotNil(t, funding.MerklePath)
+		height, err := chain.CurrentHeight(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), height)
+
+		parent := w.pay(t, funding, 9000)
+		_, failure := parent.Broadcast(chain)
+		require.Nil(t, failure)
+		child := w.pay(t, parent, 8000)
+		_, failure = child.Broadcast(chain)
+		require.Nil(t, failure)
+		require.Len(t, chain.Mempool(), 2)
+
+		// An unconfirmed chain verifies from the proof of the funding.
+		beef, err := child.BEEF()
+		require.NoError(t, err)
+		fromBeef, err := transaction.NewTransactionFromBEEF(beef)
+		require.NoError(t, err)
+		valid, err := spv.VerifyContext(ctx, fromBeef, chain, nil)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		header, err := chain.Mine()
+		require.NoError(t, err)
+		require.Empty(t, chain.Mempool())
+		require.NotNil(t, child.MerklePath)
+		require.Equal(t, uint32(2), child.MerklePath.BlockHeight)
+		root, err := child.MerklePath.ComputeRoot(child.TxID())
+		require.NoError(t, err)
+		require.Equal(t, header.MerkleRoot, *root)
+
+		valid, err = child.MerklePath.VerifyContext(ctx, child.TxID(), chain)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		stored, err := chain.Transaction(parent.TxID())
+		require.NoError(t, err)
+		require.Same(t, parent, stored)
+		require.False(t, chain.IsUnspent(parent.TxID(), 0))
+		require.True(t, chain.IsUnspent(child.TxID(), 0))
+	})
+
+	t.Run("rejects double spends", func(t *testing.T) {
+		chain := mockchain.New()
+		w := newWallet(t)
+		funding, err := chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+
+		_, failure := w.pay(t, funding, 9000).Broadcast(chain)
+		require.Nil(t, failure)
+		require.ErrorIs(t, chain.Accept(w.pay(t, funding, 8000)), mockchain.ErrDoubleSpend)
+
+		_, err = chain.Mine()
+		require.NoError(t, err)
+		_, failure = w.pay(t, funding, 7000).Broadcast(chain)
+		require.NotNil(t, failure)
+		require.Equal(t, "460", failure.Code)
+	})
+
+	t.Run("rejects rebroadcasts as already known", func(t *testing.T) {
+		chain := mockchain.New()
+		w := newWallet(t)
+		funding, err := chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+
+		tx := w.pay(t, funding, 9000)
+		require.NoError(t, chain.Accept(tx))
+		require.ErrorIs(t, chain.Accept(tx), mockchain.ErrAlreadyKnown)
+	})
+
+	t.Run("rejects invalid transactions", func(t *testing.T) {
+		chain := mockchain.New()
+		w := newWallet(t)
+		funding, err := chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+
+		// Signed by another key.
+		tx := newWallet(t).pay(t, funding, 9000)
+		require.ErrorIs(t, chain.Accept(tx), mockchain.ErrInvalidTransaction)
+
+		// Spending more than its inputs.
+		tx = w.pay(t, funding, 10001)
+		require.ErrorIs(t, chain.Accept(tx), mockchain.ErrInvalidTransaction)
+
+		// Tampered after signing.
+		tx = w.pay(t, funding, 9000)
+		tx.Outputs[0].Satoshis = 8000
+		require.ErrorIs(t, chain.Accept(tx), mockchain.ErrInvalidTransaction)
+
+		require.True(t, chain.IsUnspent(funding.TxID(), 0))
+	})
+
+	t.Run("rejects unknown inputs", func(t *testing.T) {
+		chain := mockchain.New()
+		w := newWallet(t)
+		other := mockchain.New()
+		funding, err := other.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, chain.Accept(w.pay(t, funding, 9000)), mockchain.ErrMissingInputs)
+	})
+
+	t.Run("rejects roots of other blocks", func(t *testing.T) {
+		chain := mockchain.New()
+		w := newWallet(t)
+		first, err := chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+		_, err = chain.FundScript(w.lock, 10000)
+		require.NoError(t, err)
+
+		// The funding transaction is alone in its block.
+		valid, err := chain.IsValidRootForHeight(ctx, first.TxID(), 1)
+		require.NoError(t, err)
+		require.True(t, valid)
+		valid, err = chain.IsValidRootForHeight(ctx, first.TxID(), 2)
+		require.NoError(t, err)
+		require.False(t, valid)
+
+		// Blocks not mined yet can not be verified.
+		_, err = chain.IsValidRootForHeight(ctx, first.TxID(), 3)
+		require.ErrorIs(t, err, headerchain.ErrHeightNotFound)
+	})
+}
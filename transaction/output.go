@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -48,8 +49,7 @@ func (o *TransactionOutput) ReadFrom(r io.Reader) (int64, error) {
 		return bytesRead, err
 	}
 
-	scriptBytes := make([]byte, l)
-	n, err = io.ReadFull(r, scriptBytes)
+	scriptBytes, n, err := readBytes(r, l)
 	bytesRead += int64(n)
 	if err != nil {
 		return bytesRead, errors.Wrapf(err, "lockingScript(%d): got %d bytes", l, n)
@@ -76,15 +76,22 @@ script:    %s
 
 // Bytes encodes the Output into a byte array.
 func (o *TransactionOutput) Bytes() []byte {
-	b := make([]byte, 8)
-	binary.LittleEndian.PutUint64(b, o.Satoshis)
+	buf := new(bytes.Buffer)
+	o.writeTo(&countingWriter{w: buf})
+	return buf.Bytes()
+}
 
-	h := make([]byte, 0)
-	h = append(h, b...)
-	h = append(h, VarInt(uint64(len(*o.LockingScript))).Bytes()...)
-	h = append(h, *o.LockingScript...)
+// WriteTo writes the encoded Output to the `io.Writer`.
+func (o *TransactionOutput) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	o.writeTo(cw)
+	return cw.n, cw.err
+}
 
-	return h
+func (o *TransactionOutput) writeTo(cw *countingWriter) {
+	cw.writeUint64(o.Satoshis)
+	cw.writeVarInt(VarInt(len(*o.LockingScript)))
+	cw.write(*o.LockingScript)
 }
 
 // BytesForSigHash returns the proper serialization
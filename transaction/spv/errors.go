@@ -0,0 +1,41 @@
+package spv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+)
+
+// Sentinel errors reported by Verify.
+var (
+	ErrMissingSourceTransaction = errors.New("missing source transaction")
+	ErrMissingSourceOutput      = errors.New("source transaction does not have the spent output")
+	ErrSourceTxidMismatch       = errors.New("source transaction is not the one the input spends")
+	ErrInvalidMerklePath        = errors.New("merkle path is not valid for the block height")
+	ErrScriptVerification       = errors.New("script verification failed")
+	ErrOutputsExceedInputs      = errors.New("outputs exceed inputs")
+	ErrInsufficientFee          = errors.New("fee paid is less than required by the fee model")
+)
+
+// VerificationError reports which transaction, and optionally which input,
+// failed verification and why. Use errors.Is against the sentinel errors of
+// this package to find the reason.
+type VerificationError struct {
+	TxID *chainhash.Hash
+	// InputIndex is the index of the failing input, or -1 when the failure
+	// relates to the transaction as a whole.
+	InputIndex int
+	Err        error
+}
+
+func (e *VerificationError) Error() string {
+	if e.InputIndex < 0 {
+		return fmt.Sprintf("tx %s: %v", e.TxID, e.Err)
+	}
+	return fmt.Sprintf("tx %s input %d: %v", e.TxID, e.InputIndex, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
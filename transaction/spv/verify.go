@@ -0,0 +1,137 @@
+// Package spv implements Simplified Payment Verification of transactions and
+// their ancestry, following the rules described in docs/concepts/TX_VALID.md.
+//
+// It lives outside of the transaction package because script execution
+// depends on script/interpreter, which itself depends on transaction.
+package spv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
+)
+
+// Verify checks that tx is valid according to SPV rules. Every input's
+// scripts are executed, outputs must not exceed inputs and the source
+// transactions are walked recursively until each branch ends at a
+// transaction whose MerklePath is confirmed by the chain tracker.
+//
+// When feeModel is not nil, an unmined tx must also pay at least the fee the
+// model computes. Ancestors are not checked against the fee model.
+//
+// On failure the returned error is a *VerificationError describing which
+// transaction and input failed.
+func Verify(tx *transaction.Transaction, ct chaintracker.ChainTracker, feeModel transaction.FeeModel) (bool, error) {
+	return VerifyContext(context.Background(), tx, ct, feeModel)
+}
+
+// VerifyContext is like Verify with a context passed to the chain tracker.
+// When the chain tracker fails to answer, its error is wrapped in the
+// *VerificationError instead of ErrInvalidMerklePath.
+func VerifyContext(ctx context.Context, tx *transaction.Transaction, ct chaintracker.ChainTracker, feeModel transaction.FeeModel) (bool, error) {
+	v := &verifier{
+		ctx:          ctx,
+		chainTracker: ct,
+		verified:     make(map[string]struct{}),
+	}
+	if err := v.verify(tx); err != nil {
+		return false, err
+	}
+	if feeModel != nil && tx.MerklePath == nil {
+		if err := verifyFee(tx, feeModel); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+type verifier struct {
+	ctx          context.Context
+	chainTracker chaintracker.ChainTracker
+	verified     map[string]struct{}
+}
+
+func (v *verifier) verify(tx *transaction.Transaction) error {
+	txid := tx.TxID()
+	if _, ok := v.verified[txid.String()]; ok {
+		return nil
+	}
+
+	// A transaction with a valid proof of inclusion is accepted as is,
+	// its ancestry has already been validated by the miners.
+	if tx.MerklePath != nil {
+		root, err := tx.MerklePath.ComputeRoot(txid)
+		if err != nil {
+			return &VerificationError{TxID: txid, InputIndex: -1, Err: fmt.Errorf("%w: %w", ErrInvalidMerklePath, err)}
+		}
+		valid, err := v.chainTracker.IsValidRootForHeight(v.ctx, root, tx.MerklePath.BlockHeight)
+		if err != nil {
+			return &VerificationError{TxID: txid, InputIndex: -1, Err: err}
+		}
+		if !valid {
+			return &VerificationError{TxID: txid, InputIndex: -1, Err: ErrInvalidMerklePath}
+		}
+		v.verified[txid.String()] = struct{}{}
+		return nil
+	}
+
+	satsIn := uint64(0)
+	for vin, input := range tx.Inputs {
+		if input.SourceTransaction == nil {
+			return &VerificationError{TxID: txid, InputIndex: vin, Err: ErrMissingSourceTransaction}
+		}
+		if sourceTxid := input.SourceTransaction.TxID(); input.SourceTXID == nil || !sourceTxid.IsEqual(input.SourceTXID) {
+			return &VerificationError{
+				TxID:       txid,
+				InputIndex: vin,
+				Err:        fmt.Errorf("%w: attached %s", ErrSourceTxidMismatch, sourceTxid),
+			}
+		}
+		if int(input.SourceTxOutIndex) >= len(input.SourceTransaction.Outputs) {
+			return &VerificationError{TxID: txid, InputIndex: vin, Err: ErrMissingSourceOutput}
+		}
+		if err := v.verify(input.SourceTransaction); err != nil {
+			return err
+		}
+
+		sourceOutput := input.SourceTxOutput()
+		if err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, vin, sourceOutput),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return &VerificationError{TxID: txid, InputIndex: vin, Err: fmt.Errorf("%w: %w", ErrScriptVerification, err)}
+		}
+		satsIn += sourceOutput.Satoshis
+	}
+
+	if satsOut := tx.TotalOutputSatoshis(); satsOut > satsIn {
+		return &VerificationError{
+			TxID:       txid,
+			InputIndex: -1,
+			Err:        fmt.Errorf("%w: %d > %d", ErrOutputsExceedInputs, satsOut, satsIn),
+		}
+	}
+
+	v.verified[txid.String()] = struct{}{}
+	return nil
+}
+
+func verifyFee(tx *transaction.Transaction, feeModel transaction.FeeModel) error {
+	required, err := feeModel.ComputeFee(tx)
+	if err != nil {
+		return &VerificationError{TxID: tx.TxID(), InputIndex: -1, Err: err}
+	}
+	paid := tx.TotalInputSatoshis() - tx.TotalOutputSatoshis()
+	if paid < required {
+		return &VerificationError{
+			TxID:       tx.TxID(),
+			InputIndex: -1,
+			Err:        fmt.Errorf("%w: paid %d, required %d", ErrInsufficientFee, paid, required),
+		}
+	}
+	return nil
+}
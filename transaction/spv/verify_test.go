@@ -0,0 +1,179 @@
+package spv_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	feemodel "github.com/bitcoin-sv/go-sdk/transaction/fee_model"
+	"github.com/bitcoin-sv/go-sdk/transaction/spv"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+const BRC62Hex = "0100beef01fe636d0c0007021400fe507c0c7aa754cef1f7889d5fd395cf1f785dd7de98eed895dbedfe4e5bc70d1502ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e010b00bc4ff395efd11719b277694cface5aa50d085a0bb81f613f70313acd28cf4557010400574b2d9142b8d28b61d88e3b2c3f44d858411356b49a28a4643b6d1a6a092a5201030051a05fc84d531b5d250c23f4f886f6812f9fe3f402d61607f977b4ecd2701c19010000fd781529d58fc2523cf396a7f25440b409857e7e221766c57214b1d38c7b481f01010062f542f45ea3660f86c013ced80534cb5fd4c19d66c56e7e8c5d4bf2d40acc5e010100b121e91836fd7cd5102b654e9f72f3cf6fdbfd0b161c53a9c54b12c841126331020100000001cd4e4cac3c7b56920d1e7655e7e260d31f29d9a388d04910f1bbd72304a79029010000006b483045022100e75279a205a547c445719420aa3138bf14743e3f42618e5f86a19bde14bb95f7022064777d34776b05d816daf1699493fcdf2ef5a5ab1ad710d9c97bfb5b8f7cef3641210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013e660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000001000100000001ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e000000006a47304402203a61a2e931612b4bda08d541cfb980885173b8dcf64a3471238ae7abcd368d6402204cbf24f04b9aa2256d8901f0ed97866603d2be8324c2bfb7a37bf8fc90edd5b441210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013c660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000000"
+
+// rootTracker accepts a single merkle root at a single height, or fails
+// with err when set.
+type rootTracker struct {
+	root   *chainhash.Hash
+	height uint32
+	err    error
+}
+
+func (r *rootTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	return root.IsEqual(r.root) && height == r.height, nil
+}
+
+func (r *rootTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	return r.height, nil
+}
+
+func trackerFor(t *testing.T, tx *transaction.Transaction) *rootTracker {
+	mp := tx.MerklePath
+	root, err := mp.ComputeRoot(tx.TxID())
+	require.NoError(t, err)
+	return &rootTracker{root: root, height: mp.BlockHeight}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("verifies a valid BEEF", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		tracker := trackerFor(t, tx.Inputs[0].SourceTransaction)
+
+		valid, err := spv.Verify(tx, tracker, &feemodel.SatoshisPerKilobyte{Satoshis: 1})
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("rejects an unknown merkle root", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		parent := tx.Inputs[0].SourceTransaction
+		tracker := trackerFor(t, parent)
+		tracker.height++
+
+		valid, err := spv.Verify(tx, tracker, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrInvalidMerklePath)
+
+		var verr *spv.VerificationError
+		require.True(t, errors.As(err, &verr))
+		require.Equal(t, parent.TxID(), verr.TxID)
+		require.Equal(t, -1, verr.InputIndex)
+	})
+
+	t.Run("reports chain tracker failures", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		tracker := trackerFor(t, tx.Inputs[0].SourceTransaction)
+		tracker.err = errors.New("service unavailable")
+
+		valid, err := spv.VerifyContext(context.Background(), tx, tracker, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, tracker.err)
+		require.False(t, errors.Is(err, spv.ErrInvalidMerklePath))
+	})
+
+	t.Run("rejects a missing source transaction", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		tx.Inputs[0].SourceTransaction = nil
+
+		valid, err := spv.Verify(tx, &rootTracker{}, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrMissingSourceTransaction)
+
+		var verr *spv.VerificationError
+		require.True(t, errors.As(err, &verr))
+		require.Equal(t, 0, verr.InputIndex)
+	})
+
+	t.Run("rejects a source transaction of another txid", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		parent := tx.Inputs[0].SourceTransaction
+
+		// A proven transaction alone in its block, paying more to the same
+		// locking script than the actual source transaction.
+		other := transaction.NewTransaction()
+		other.AddOutput(&transaction.TransactionOutput{
+			Satoshis:      parent.Outputs[tx.Inputs[0].SourceTxOutIndex].Satoshis * 2,
+			LockingScript: parent.Outputs[tx.Inputs[0].SourceTxOutIndex].LockingScript,
+		})
+		isTxid := true
+		other.MerklePath = transaction.NewMerklePath(100, [][]*transaction.PathElement{
+			{{Offset: 0, Hash: other.TxID(), Txid: &isTxid}},
+		})
+		tx.Inputs[0].SourceTransaction = other
+		tx.Inputs[0].SourceTxOutIndex = 0
+
+		valid, err := spv.Verify(tx, &rootTracker{root: other.TxID(), height: 100}, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrSourceTxidMismatch)
+
+		var verr *spv.VerificationError
+		require.True(t, errors.As(err, &verr))
+		require.Equal(t, tx.TxID(), verr.TxID)
+		require.Equal(t, 0, verr.InputIndex)
+	})
+
+	t.Run("rejects an invalid signature", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		tracker := trackerFor(t, tx.Inputs[0].SourceTransaction)
+		tx.Outputs[0].Satoshis--
+
+		valid, err := spv.Verify(tx, tracker, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrScriptVerification)
+	})
+
+	t.Run("rejects outputs exceeding inputs", func(t *testing.T) {
+		priv, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		address, err := script.NewAddressFromPublicKey(priv.PubKey(), true)
+		require.NoError(t, err)
+		lock, err := p2pkh.Lock(address)
+		require.NoError(t, err)
+
+		// A proven source transaction alone in its block, so the root is its txid.
+		source := transaction.NewTransaction()
+		source.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: lock})
+		isTxid := true
+		source.MerklePath = transaction.NewMerklePath(100, [][]*transaction.PathElement{
+			{{Offset: 0, Hash: source.TxID(), Txid: &isTxid}},
+		})
+
+		unlocker, err := p2pkh.Unlock(priv, nil)
+		require.NoError(t, err)
+		tx := transaction.NewTransaction()
+		tx.AddInputFromTx(source, 0, unlocker)
+		tx.AddOutput(&transaction.TransactionOutput{Satoshis: 2000, LockingScript: lock})
+		require.NoError(t, tx.Sign())
+
+		valid, err := spv.Verify(tx, &rootTracker{root: source.TxID(), height: 100}, nil)
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrOutputsExceedInputs)
+	})
+
+	t.Run("rejects an insufficient fee", func(t *testing.T) {
+		tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+		require.NoError(t, err)
+		tracker := trackerFor(t, tx.Inputs[0].SourceTransaction)
+
+		valid, err := spv.Verify(tx, tracker, &feemodel.SatoshisPerKilobyte{Satoshis: 100})
+		require.False(t, valid)
+		require.ErrorIs(t, err, spv.ErrInsufficientFee)
+	})
+}
@@ -0,0 +1,236 @@
+// Package multisig implements bare m-of-n multisig scripts, unlocked by
+// signatures of m of the n public keys.
+//
+// Cosigners on separate machines each sign the input with the keys they
+// hold, producing a partial unlocking script, and Merge combines the
+// partial unlocking scripts into the one of the input.
+package multisig
+
+import (
+	"errors"
+
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	sighash "github.com/bitcoin-sv/go-sdk/transaction/sighash"
+)
+
+// MaxPublicKeys is the number of public keys of the scripts Lock returns at
+// most, their count being pushed as a small integer.
+const MaxPublicKeys = 16
+
+var (
+	ErrBadThreshold        = errors.New("required signatures must be between 1 and the number of public keys")
+	ErrTooManyPublicKeys   = errors.New("too many public keys")
+	ErrBadScript           = errors.New("not a multisig locking script")
+	ErrNoPrivateKey        = errors.New("private key not supplied")
+	ErrNoMatchingKey       = errors.New("no private key matches the public keys of the script")
+	ErrBadUnlockingScript  = errors.New("not a multisig unlocking script")
+	ErrInvalidSignature    = errors.New("signature matches no public key of the script")
+	ErrNotEnoughSignatures = errors.New("not enough signatures")
+)
+
+// Lock returns the script locking to m signatures of the public keys,
+// checked in the order given.
+func Lock(pubKeys []*ec.PublicKey, m int) (*script.Script, error) {
+	if len(pubKeys) > MaxPublicKeys {
+		return nil, ErrTooManyPublicKeys
+	}
+	if m < 1 || m > len(pubKeys) {
+		return nil, ErrBadThreshold
+	}
+	s := &script.Script{}
+	_ = s.AppendOpcodes(script.Op1 - 1 + byte(m))
+	for _, pubKey := range pubKeys {
+		if err := s.AppendPushData(pubKey.SerializeCompressed()); err != nil {
+			return nil, err
+		}
+	}
+	_ = s.AppendOpcodes(script.Op1-1+byte(len(pubKeys)), script.OpCHECKMULTISIG)
+	return s, nil
+}
+
+// Decode returns the public keys of a multisig locking script and the
+// number of signatures it requires.
+func Decode(s *script.Script) ([]*ec.PublicKey, int, error) {
+	chunks, err := script.DecodeScript(*s)
+	if err != nil || len(chunks) < 4 {
+		return nil, 0, ErrBadScript
+	}
+	m, ok := smallInt(chunks[0].Op)
+	n, nOk := smallInt(chunks[len(chunks)-2].Op)
+	if !ok || !nOk || chunks[len(chunks)-1].Op != script.OpCHECKMULTISIG ||
+		n != len(chunks)-3 || m > n {
+		return nil, 0, ErrBadScript
+	}
+	pubKeys := make([]*ec.PublicKey, n)
+	for i, chunk := range chunks[1 : n+1] {
+		if pubKeys[i], err = ec.ParsePubKey(chunk.Data); err != nil {
+			return nil, 0, ErrBadScript
+		}
+	}
+	return pubKeys, m, nil
+}
+
+func smallInt(op byte) (int, bool) {
+	if op < script.Op1 || op > script.Op16 {
+		return 0, false
+	}
+	return int(op-script.Op1) + 1, true
+}
+
+// Unlock returns a template signing with keys, some of the keys of the
+// locking script. sigHashFlag defaults to sighash.AllForkID when nil.
+func Unlock(keys []*ec.PrivateKey, sigHashFlag *sighash.Flag) (*Multisig, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoPrivateKey
+	}
+	if sigHashFlag == nil {
+		shf := sighash.AllForkID
+		sigHashFlag = &shf
+	}
+	return &Multisig{
+		PrivateKeys: keys,
+		SigHashFlag: sigHashFlag,
+	}, nil
+}
+
+type Multisig struct {
+	PrivateKeys []*ec.PrivateKey
+	SigHashFlag *sighash.Flag
+}
+
+// Sign returns the unlocking script of the signatures of the keys held
+// among those of the locking script, as many as it requires at most. With
+// fewer keys than required, the unlocking script is partial, to be merged
+// with those of the other cosigners.
+func (p *Multisig) Sign(tx *transaction.Transaction, inputIndex uint32) (*script.Script, error) {
+	pubKeys, m, err := sourceScript(tx, inputIndex)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := tx.CalcInputSignatureHash(inputIndex, *p.SigHashFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dummy element consumed by OP_CHECKMULTISIG.
+	s := &script.Script{script.Op0}
+	signed := 0
+	for _, pubKey := range pubKeys {
+		key := p.keyOf(pubKey)
+		if key == nil {
+			continue
+		}
+		sig, err := key.Sign(sh)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.AppendPushData(append(sig.Serialize(), uint8(*p.SigHashFlag))); err != nil {
+			return nil, err
+		}
+		if signed++; signed == m {
+			break
+		}
+	}
+	if signed == 0 {
+		return nil, ErrNoMatchingKey
+	}
+	return s, nil
+}
+
+// EstimateLength returns the length of the complete unlocking script, of
+// the largest signatures.
+func (p *Multisig) EstimateLength(tx *transaction.Transaction, inputIndex uint32) uint32 {
+	m := len(p.PrivateKeys)
+	if _, required, err := sourceScript(tx, inputIndex); err == nil {
+		m = required
+	}
+	// OP_0, then pushes of DER signatures of up to 72 bytes and the
+	// sighash flag.
+	return 1 + 74*uint32(m)
+}
+
+func (p *Multisig) keyOf(pubKey *ec.PublicKey) *ec.PrivateKey {
+	for _, key := range p.PrivateKeys {
+		if key.PubKey().IsEqual(pubKey) {
+			return key
+		}
+	}
+	return nil
+}
+
+// Merge combines the partial unlocking scripts of the input signed by
+// cosigners into its unlocking script, ordering the signatures as the
+// public keys of the locking script and keeping as many as it requires.
+// It fails with ErrNotEnoughSignatures when the partial unlocking scripts
+// hold too few signatures.
+func Merge(tx *transaction.Transaction, inputIndex uint32, partials ...*script.Script) (*script.Script, error) {
+	pubKeys, m, err := sourceScript(tx, inputIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	sigs := make([][]byte, len(pubKeys))
+	for _, partial := range partials {
+		chunks, err := script.DecodeScript(*partial)
+		if err != nil || len(chunks) == 0 || chunks[0].Op != script.Op0 {
+			return nil, ErrBadUnlockingScript
+		}
+		for _, chunk := range chunks[1:] {
+			i, err := signer(tx, inputIndex, pubKeys, chunk.Data)
+			if err != nil {
+				return nil, err
+			}
+			sigs[i] = chunk.Data
+		}
+	}
+
+	s := &script.Script{script.Op0}
+	signed := 0
+	for _, sig := range sigs {
+		if sig == nil {
+			continue
+		}
+		if err := s.AppendPushData(sig); err != nil {
+			return nil, err
+		}
+		if signed++; signed == m {
+			return s, nil
+		}
+	}
+	return nil, ErrNotEnoughSignatures
+}
+
+// signer returns the index of the public key which made the signature,
+// followed by its sighash flag.
+func signer(tx *transaction.Transaction, inputIndex uint32, pubKeys []*ec.PublicKey, data []byte) (int, error) {
+	if len(data) < 2 {
+		return 0, ErrBadUnlockingScript
+	}
+	sig, err := ec.ParseDERSignature(data[:len(data)-1])
+	if err != nil {
+		return 0, ErrBadUnlockingScript
+	}
+	sh, err := tx.CalcInputSignatureHash(inputIndex, sighash.Flag(data[len(data)-1]))
+	if err != nil {
+		return 0, err
+	}
+	for i, pubKey := range pubKeys {
+		if sig.Verify(sh, pubKey) {
+			return i, nil
+		}
+	}
+	return 0, ErrInvalidSignature
+}
+
+func sourceScript(tx *transaction.Transaction, inputIndex uint32) ([]*ec.PublicKey, int, error) {
+	if int(inputIndex) >= len(tx.Inputs) {
+		return nil, 0, transaction.ErrInputNoExist
+	}
+	output := tx.Inputs[inputIndex].SourceTxOutput()
+	if output == nil {
+		return nil, 0, transaction.ErrEmptyPreviousTx
+	}
+	return Decode(output.LockingScript)
+}
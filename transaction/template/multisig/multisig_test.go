@@ -0,0 +1,145 @@
+package multisig_test
+
+import (
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	"github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/multisig"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeys(t *testing.T, n int) ([]*ec.PrivateKey, []*ec.PublicKey) {
+	keys := make([]*ec.PrivateKey, n)
+	pubKeys := make([]*ec.PublicKey, n)
+	for i := range keys {
+		key, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		keys[i], pubKeys[i] = key, key.PubKey()
+	}
+	return keys, pubKeys
+}
+
+// spendingTx returns a transaction spending an output locked by lock.
+func spendingTx(lock *script.Script) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	input := &transaction.TransactionInput{SourceTXID: &chainhash.Hash{1}}
+	input.SetSourceTxOutput(&transaction.TransactionOutput{Satoshis: 10000, LockingScript: lock})
+	tx.AddInput(input)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 9000, LockingScript: &script.Script{script.OpTRUE}})
+	return tx
+}
+
+func verify(tx *transaction.Transaction) error {
+	return interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, 0, tx.Inputs[0].SourceTxOutput()),
+		interpreter.WithForkID(),
+		interpreter.WithAfterGenesis(),
+	)
+}
+
+func TestLock(t *testing.T) {
+	_, pubKeys := newKeys(t, 3)
+	lock, err := multisig.Lock(pubKeys, 2)
+	require.NoError(t, err)
+	require.True(t, lock.IsMultiSigOut())
+	require.Len(t, *lock, 1+3*34+2)
+
+	decoded, m, err := multisig.Decode(lock)
+	require.NoError(t, err)
+	require.Equal(t, 2, m)
+	for i, pubKey := range pubKeys {
+		require.True(t, pubKey.IsEqual(decoded[i]))
+	}
+
+	_, err = multisig.Lock(pubKeys, 0)
+	require.ErrorIs(t, err, multisig.ErrBadThreshold)
+	_, err = multisig.Lock(pubKeys, 4)
+	require.ErrorIs(t, err, multisig.ErrBadThreshold)
+	_, manyKeys := newKeys(t, multisig.MaxPublicKeys+1)
+	_, err = multisig.Lock(manyKeys, 2)
+	require.ErrorIs(t, err, multisig.ErrTooManyPublicKeys)
+
+	_, _, err = multisig.Decode(&script.Script{script.OpTRUE})
+	require.ErrorIs(t, err, multisig.ErrBadScript)
+}
+
+func TestMultisig(t *testing.T) {
+	keys, pubKeys := newKeys(t, 3)
+	lock, err := multisig.Lock(pubKeys, 2)
+	require.NoError(t, err)
+
+	t.Run("signs with the keys held", func(t *testing.T) {
+		tx := spendingTx(lock)
+		unlocker, err := multisig.Unlock([]*ec.PrivateKey{keys[2], keys[0]}, nil)
+		require.NoError(t, err)
+		var _ transaction.UnlockingScriptTemplate = unlocker
+		tx.Inputs[0].UnlockingScriptTemplate = unlocker
+		require.NoError(t, tx.Sign())
+
+		unlock := *tx.Inputs[0].UnlockingScript
+		require.Equal(t, script.Op0, unlock[0])
+		require.LessOrEqual(t, len(unlock), int(unlocker.EstimateLength(tx, 0)))
+		require.Equal(t, uint32(1+2*74), unlocker.EstimateLength(tx, 0))
+		require.NoError(t, verify(tx))
+	})
+
+	t.Run("signs no more than required", func(t *testing.T) {
+		tx := spendingTx(lock)
+		unlocker, err := multisig.Unlock(keys, nil)
+		require.NoError(t, err)
+		unlock, err := unlocker.Sign(tx, 0)
+		require.NoError(t, err)
+		chunks, err := script.DecodeScript(*unlock)
+		require.NoError(t, err)
+		require.Len(t, chunks, 3)
+	})
+
+	t.Run("merges the signatures of cosigners", func(t *testing.T) {
+		tx := spendingTx(lock)
+
+		// Each cosigner signs a copy of the transaction on its machine.
+		partials := make([]*script.Script, 0, 2)
+		for _, key := range []*ec.PrivateKey{keys[2], keys[1]} {
+			cosignerTx, err := transaction.NewTransactionFromHex(tx.Hex())
+			require.NoError(t, err)
+			cosignerTx.Inputs[0].SetSourceTxOutput(tx.Inputs[0].SourceTxOutput())
+			unlocker, err := multisig.Unlock([]*ec.PrivateKey{key}, nil)
+			require.NoError(t, err)
+			partial, err := unlocker.Sign(cosignerTx, 0)
+			require.NoError(t, err)
+			partials = append(partials, partial)
+		}
+
+		_, err := multisig.Merge(tx, 0, partials[0])
+		require.ErrorIs(t, err, multisig.ErrNotEnoughSignatures)
+
+		unlock, err := multisig.Merge(tx, 0, partials...)
+		require.NoError(t, err)
+		tx.Inputs[0].UnlockingScript = unlock
+		require.NoError(t, verify(tx))
+	})
+
+	t.Run("rejects signatures of other keys", func(t *testing.T) {
+		tx := spendingTx(lock)
+		otherKeys, otherPubKeys := newKeys(t, 1)
+		otherLock, err := multisig.Lock(otherPubKeys, 1)
+		require.NoError(t, err)
+		unlocker, err := multisig.Unlock(otherKeys, nil)
+		require.NoError(t, err)
+
+		_, err = unlocker.Sign(tx, 0)
+		require.ErrorIs(t, err, multisig.ErrNoMatchingKey)
+
+		partial, err := unlocker.Sign(spendingTx(otherLock), 0)
+		require.NoError(t, err)
+		_, err = multisig.Merge(tx, 0, partial)
+		require.ErrorIs(t, err, multisig.ErrInvalidSignature)
+
+		_, err = multisig.Merge(tx, 0, &script.Script{script.OpTRUE})
+		require.ErrorIs(t, err, multisig.ErrBadUnlockingScript)
+	})
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/bitcoin-sv/go-sdk/chainhash"
 	crypto "github.com/bitcoin-sv/go-sdk/primitives/hash"
 	"github.com/bitcoin-sv/go-sdk/script"
-	"github.com/bitcoin-sv/go-sdk/util"
 	"github.com/pkg/errors"
 )
 
@@ -304,6 +303,27 @@ func (tx *Transaction) EF() ([]byte, error) {
 	return tx.toBytesHelper(0, nil, true), nil
 }
 
+// WriteTo writes the transaction to the `io.Writer` in the same format as
+// Bytes, without buffering it in memory first.
+func (tx *Transaction) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tx.writeTo(cw, 0, nil, false)
+	return cw.n, cw.err
+}
+
+// WriteEFTo writes the transaction to the `io.Writer` in extended format, in
+// the same way as EF.
+func (tx *Transaction) WriteEFTo(w io.Writer) (int64, error) {
+	for _, in := range tx.Inputs {
+		if in.SourceTransaction == nil && in.sourceOutput == nil {
+			return 0, ErrEmptyPreviousTx
+		}
+	}
+	cw := &countingWriter{w: w}
+	tx.writeTo(cw, 0, nil, true)
+	return cw.n, cw.err
+}
+
 func (tx *Transaction) EFHex() (string, error) {
 	ef, err := tx.EF()
 	if err != nil {
@@ -381,51 +401,47 @@ func (tx *Transaction) ShallowClone() *Transaction {
 }
 
 func (tx *Transaction) toBytesHelper(index int, lockingScript []byte, extended bool) []byte {
-	h := make([]byte, 0)
+	buf := new(bytes.Buffer)
+	tx.writeTo(&countingWriter{w: buf}, index, lockingScript, extended)
+	return buf.Bytes()
+}
 
-	h = append(h, util.LittleEndianBytes(tx.Version, 4)...)
+func (tx *Transaction) writeTo(cw *countingWriter, index int, lockingScript []byte, extended bool) {
+	cw.writeUint32(tx.Version)
 
 	if extended {
-		h = append(h, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xEF}...)
+		cw.write([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xEF})
 	}
 
-	h = append(h, VarInt(uint64(len(tx.Inputs))).Bytes()...)
+	cw.writeVarInt(VarInt(len(tx.Inputs)))
 
 	for i, in := range tx.Inputs {
-		s := in.Bytes(lockingScript != nil)
 		if i == index && lockingScript != nil {
-			h = append(h, VarInt(uint64(len(lockingScript))).Bytes()...)
-			h = append(h, lockingScript...)
+			cw.writeVarInt(VarInt(len(lockingScript)))
+			cw.write(lockingScript)
 		} else {
-			h = append(h, s...)
+			in.writeTo(cw, lockingScript != nil)
 		}
 
 		if extended {
-			b := make([]byte, 8)
 			sourceTxOut := in.SourceTxOutput()
 			if sourceTxOut != nil {
-				binary.LittleEndian.PutUint64(b, sourceTxOut.Satoshis)
-				h = append(h, b...)
-				l := uint64(len(*sourceTxOut.LockingScript))
-				h = append(h, VarInt(l).Bytes()...)
-				h = append(h, *sourceTxOut.LockingScript...)
+				cw.writeUint64(sourceTxOut.Satoshis)
+				cw.writeVarInt(VarInt(len(*sourceTxOut.LockingScript)))
+				cw.write(*sourceTxOut.LockingScript)
 			} else {
-				binary.LittleEndian.PutUint64(b, 0)
-				h = append(h, b...)
-				h = append(h, 0x00) // The length of the script is zero
+				cw.writeUint64(0)
+				cw.writeByte(0x00) // The length of the script is zero
 			}
 		}
 	}
 
-	h = append(h, VarInt(uint64(len(tx.Outputs))).Bytes()...)
+	cw.writeVarInt(VarInt(len(tx.Outputs)))
 	for _, out := range tx.Outputs {
-		h = append(h, out.Bytes()...)
+		out.writeTo(cw)
 	}
 
-	lt := make([]byte, 4)
-	binary.LittleEndian.PutUint32(lt, tx.LockTime)
-
-	return append(h, lt...)
+	cw.writeUint32(tx.LockTime)
 }
 
 // Size will return the size of tx in bytes.
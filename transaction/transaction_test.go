@@ -1,6 +1,7 @@
 package transaction_test
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
 
@@ -94,6 +95,53 @@ func TestEF(t *testing.T) {
 	})
 }
 
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
+	require.NoError(t, err)
+
+	t.Run("raw", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := tx.WriteTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+		require.Equal(t, tx.Bytes(), buf.Bytes())
+	})
+
+	t.Run("extended format", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := tx.WriteEFTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+		ef, err := tx.EF()
+		require.NoError(t, err)
+		require.Equal(t, ef, buf.Bytes())
+
+		orphan := tx.ShallowClone()
+		orphan.Inputs[0].SetSourceTxOutput(nil)
+		_, err = orphan.WriteEFTo(&buf)
+		require.ErrorIs(t, err, transaction.ErrEmptyPreviousTx)
+	})
+
+	t.Run("merkle path", func(t *testing.T) {
+		mp := tx.Inputs[0].SourceTransaction.MerklePath
+		var buf bytes.Buffer
+		n, err := mp.WriteTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+		require.Equal(t, mp.Bytes(), buf.Bytes())
+	})
+
+	t.Run("BEEF", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := tx.WriteBEEFTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+		require.Equal(t, BRC62Hex, hex.EncodeToString(buf.Bytes()))
+	})
+}
+
 func TestShallowClone(t *testing.T) {
 	tx, err := transaction.NewTransactionFromBEEFHex(BRC62Hex)
 	require.NoError(t, err)
@@ -1,8 +1,10 @@
 package transaction
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
+	"math"
 
 	"github.com/pkg/errors"
 )
@@ -103,6 +105,30 @@ func (v *VarInt) ReadFrom(r io.Reader) (int64, error) {
 	}
 }
 
+// maxPreallocLength is the largest length read by readBytes with a single
+// up front allocation.
+const maxPreallocLength = 1 << 16
+
+// readBytes reads the given number of bytes from the io.Reader. Larger lengths
+// are read into a growing buffer so that a corrupt length read from untrusted
+// data cannot trigger a huge allocation.
+func readBytes(r io.Reader, length VarInt) ([]byte, int, error) {
+	if length <= maxPreallocLength {
+		b := make([]byte, length)
+		n, err := io.ReadFull(r, b)
+		return b, n, err
+	}
+	if uint64(length) > math.MaxInt64 {
+		return nil, 0, errors.Errorf("length %d is too large", length)
+	}
+	buf := new(bytes.Buffer)
+	n, err := io.CopyN(buf, r, int64(length))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), int(n), err
+}
+
 // UpperLimitInc returns true if a number is at the
 // upper limit of a VarInt and will result in a VarInt
 // length change if incremented. The value returned will
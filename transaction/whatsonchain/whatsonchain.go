@@ -0,0 +1,15 @@
+// Package whatsonchain holds what the clients of the WhatsOnChain API share,
+// the networks it serves and its base url, so that the broadcaster and the
+// chain tracker do not depend on each other.
+package whatsonchain
+
+// Network is a BSV network served by WhatsOnChain.
+type Network string
+
+const (
+	MainNet Network = "main"
+	TestNet Network = "test"
+)
+
+// Url is the base url of the WhatsOnChain API.
+const Url = "https://api.whatsonchain.com/v1/bsv"
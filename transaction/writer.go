@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// countingWriter counts the bytes written to an io.Writer and keeps the
+// first error, so that encoders can write field after field and only check
+// for failure once they are done.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) write(b []byte) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) writeByte(b byte) {
+	cw.write([]byte{b})
+}
+
+func (cw *countingWriter) writeVarInt(v VarInt) {
+	cw.write(v.Bytes())
+}
+
+func (cw *countingWriter) writeUint32(v uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	cw.write(b)
+}
+
+func (cw *countingWriter) writeUint64(v uint64) {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	cw.write(b)
+}